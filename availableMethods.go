@@ -176,7 +176,7 @@ type CopyMessageParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -244,7 +244,7 @@ type SendPhotoParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -321,7 +321,7 @@ type SendAudioParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -402,7 +402,7 @@ type SendDocumentParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -485,7 +485,7 @@ type SendVideoParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -570,7 +570,7 @@ type SendAnimationParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -638,7 +638,7 @@ type SendVoiceParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -723,7 +723,7 @@ type SendVideoNoteParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -774,7 +774,7 @@ type SendMediaGroupParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -840,7 +840,7 @@ type SendLocationParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -915,7 +915,7 @@ type EditMessageLiveLocationParams struct {
 func (api *API) EditMessageLiveLocation(params *EditMessageLiveLocationParams) (*Message, error) {
 	var msg *Message
 
-	if params.InlineMessageID != "" {
+	if params.InlineMessageID == "" {
 		msg = &Message{}
 	}
 
@@ -960,7 +960,7 @@ type StopMessageLiveLocationParams struct {
 func (api *API) StopMessageLiveLocation(params *StopMessageLiveLocationParams) (*Message, error) {
 	var msg *Message
 
-	if params.InlineMessageID != "" {
+	if params.InlineMessageID == "" {
 		msg = &Message{}
 	}
 
@@ -1010,7 +1010,7 @@ type SendVenueParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -1065,7 +1065,7 @@ type SendContactParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -1147,7 +1147,7 @@ type SendPollParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -1198,7 +1198,7 @@ type SendDiceParams struct {
 	// saving
 	ProtectContent bool `json:"protect_content,omitempty"`
 	// Optional. If the message is a reply, ID of the original message
-	ReplyToMessageID bool `json:"reply_to_message_id,omitempty"`
+	ReplyToMessageID MessageID `json:"reply_to_message_id,omitempty"`
 	// Optional. Pass True, if the message should be sent even if the specified
 	// replied-to message is not found
 	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
@@ -1877,7 +1877,7 @@ type SetChatPhotoParams struct {
 	// (in the format @channelusername)
 	ChatID ChatIDOrUsername `json:"chat_id"`
 	// New chat photo, uploaded using multipart/form-data
-	Photo InputFile `json:"user_id"`
+	Photo InputFile `json:"photo"`
 }
 
 // Use this method to set a new profile photo for the chat. Photos can't be
@@ -2129,14 +2129,14 @@ type GetChatParams struct {
 // https://core.telegram.org/bots/api#chat
 //
 // https://core.telegram.org/bots/api#getchat
-func (api *API) GetChat(params *GetChatParams) (*UserProfilePhotos, error) {
-	userProfilePhotos := &UserProfilePhotos{}
+func (api *API) GetChat(params *GetChatParams) (*Chat, error) {
+	chat := &Chat{}
 
-	migrateToChatID, err := api.makeAPICall("getChat", params, nil, userProfilePhotos)
+	migrateToChatID, err := api.makeAPICall("getChat", params, nil, chat)
 	if err != nil {
 		if migrateToChatID != 0 {
 			params.ChatID = migrateToChatID
-			_, err = api.makeAPICall("getChat", params, nil, userProfilePhotos)
+			_, err = api.makeAPICall("getChat", params, nil, chat)
 			if err != nil {
 				return nil, fmt.Errorf("GetChat: %w", err)
 			}
@@ -2145,7 +2145,7 @@ func (api *API) GetChat(params *GetChatParams) (*UserProfilePhotos, error) {
 		}
 	}
 
-	return userProfilePhotos, nil
+	return chat, nil
 }
 
 type GetChatAdministratorsParams struct {
@@ -298,6 +298,9 @@ type Message struct {
 	// Optional. Inline keyboard attached to the message. `login_url` buttons
 	// are represented as ordinary `url` buttons.
 	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+
+	// JSON, from which message was decoded, see RawJSON
+	raw []byte
 }
 
 // This object represents a unique message identifier.
@@ -1398,7 +1401,7 @@ type MenuButton struct {
 
 // Describes why a request was unsuccessful.
 //
-// *Used internally by this library*
+// *Used internally by this library*, also available in APIError
 // https://core.telegram.org/bots/api#responseparameters
 type ResponseParameters struct {
 	// Optional. The group has been migrated to a supergroup with the specified
@@ -1462,9 +1465,9 @@ type InputMedia struct {
 	SupportsStreaming bool `json:"supports_streaming,omitempty"`
 
 	// Optional. Performer of the audio
-	Performer int `json:"performer,omitempty"`
+	Performer string `json:"performer,omitempty"`
 	// Optional. Title of the audio
-	Title int `json:"title,omitempty"`
+	Title string `json:"title,omitempty"`
 
 	// Optional. Disables automatic server-side content type detection for files
 	// uploaded using multipart/form-data. Always True, if the document is sent
@@ -1512,7 +1515,10 @@ func (FileURL) multipartFormFile() (fieldname string, filename string, reader io
 	return "", "", nil
 }
 
-// File reader for InputFile fields
+// File reader for InputFile fields. Reader is streamed to Telegram, without
+// buffering in memory, when API.HttpDoStreamRequest is set. If Reader has Len
+// method or implements io.Seeker (e.x. *os.File), request is sent with exact
+// Content-Length, otherwise with chunked transfer encoding.
 //
 // https://core.telegram.org/bots/api#sending-files
 type FileReader struct {
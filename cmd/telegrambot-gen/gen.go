@@ -0,0 +1,739 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"strings"
+)
+
+type generator struct {
+	spec  *Spec
+	types map[string]*SpecType
+	// Identifiers, which are already declared in target package
+	declared map[string]bool
+	// Go types of fields of structs declared in target package by type name
+	// and JSON name of field
+	declaredFields map[string]map[string]string
+	// Go types of results of API methods declared in target package
+	declaredResults map[string]string
+
+	buf *bytes.Buffer
+	// Fields missing from declared types, declarations differing from spec
+	// and problems of spec, to be fixed by hand
+	report *bytes.Buffer
+}
+
+func newGenerator(spec *Spec, decls *declarations) *generator {
+	g := &generator{
+		spec:            spec,
+		types:           map[string]*SpecType{},
+		declared:        decls.identifiers,
+		declaredFields:  decls.fields,
+		declaredResults: decls.results,
+		buf:             &bytes.Buffer{},
+		report:          &bytes.Buffer{},
+	}
+
+	for _, t := range spec.Types {
+		g.types[t.Name] = t
+	}
+
+	return g
+}
+
+// Generates Go source file of package pkg
+func (g *generator) generate(pkg string) ([]byte, error) {
+	for _, t := range g.spec.Types {
+		if len(t.SubtypeOf) != 0 || interfaceTypes[t.Name] {
+			continue
+		}
+
+		if g.declared[goTypeName(t.Name)] {
+			g.reportMissingFields(t)
+			continue
+		}
+
+		if len(t.Subtypes) != 0 {
+			g.writeUnionType(t)
+		} else {
+			g.writeType(t)
+		}
+	}
+
+	usesFmt := false
+	for _, m := range g.spec.Methods {
+		paramsType := goName(m.Name) + "Params"
+		if g.declared[paramsType] {
+			g.reportMissingMethodFields(m, paramsType)
+		}
+
+		if g.declared["API."+goName(m.Name)] {
+			g.reportResultType(m)
+			continue
+		}
+
+		g.writeMethod(m)
+		usesFmt = true
+	}
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "// Code generated by telegrambot-gen from %v. DO NOT EDIT.\n\n", g.spec.Version)
+	fmt.Fprintf(out, "package %v\n\n", pkg)
+	if usesFmt {
+		out.WriteString("import \"fmt\"\n\n")
+	}
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return out.Bytes(), fmt.Errorf("generate: %w", err)
+	}
+
+	return src, nil
+}
+
+// Writes comment with text, wrapped to fit 80 columns. Paragraphs of text are
+// separated by empty comment lines.
+func (g *generator) writeComment(indent string, paragraphs ...string) {
+	width := 80 - len(indent)*4 - len("// ")
+
+	for i, paragraph := range paragraphs {
+		if i != 0 {
+			g.buf.WriteString(indent + "//\n")
+		}
+
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && len(line)+1+len(word) > width {
+				g.buf.WriteString(indent + "// " + line + "\n")
+				line = ""
+			}
+
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+
+		if line != "" {
+			g.buf.WriteString(indent + "// " + line + "\n")
+		}
+	}
+}
+
+// Reports fields of spec type, which its declared Go type doesn't have or has
+// with other type, including fields of subtypes for union types
+func (g *generator) reportMissingFields(t *SpecType) {
+	typeName := goTypeName(t.Name)
+	declaredFields := g.declaredFields[typeName]
+	if declaredFields == nil {
+		// Not a struct, e.x. interface
+		return
+	}
+
+	subtypes := g.subtypesOf(t)
+	owners := append([]*SpecType{t}, subtypes...)
+
+	// Fields telling subtype have their own string type
+	enumTypes := map[string]string{}
+	for _, subtype := range subtypes {
+		for _, f := range subtype.Fields {
+			if fieldInAllTypes(f.Name, subtypes) && discriminatorValues(f.Name, subtypes) != nil {
+				enumTypes[f.Name] = t.Name + goName(f.Name)
+			}
+		}
+	}
+
+	g.reportFields(typeName, declaredFields, func(check func(owner string, f *SpecField, forceOmitempty bool, goType string)) {
+		for _, owner := range owners {
+			for _, f := range owner.Fields {
+				goType, ok := enumTypes[f.Name]
+				if !ok {
+					goType = g.goType(owner.Name, f)
+				}
+
+				check(owner.Name, f, owner != t, goType)
+			}
+		}
+	})
+}
+
+// Reports parameters of method, which its declared Params struct doesn't have
+func (g *generator) reportMissingMethodFields(m *SpecMethod, paramsType string) {
+	declaredFields := g.declaredFields[paramsType]
+	if declaredFields == nil {
+		return
+	}
+
+	g.reportFields(paramsType, declaredFields, func(check func(owner string, f *SpecField, forceOmitempty bool, goType string)) {
+		for _, f := range m.Fields {
+			check(m.Name, f, false, g.goType(m.Name, f))
+		}
+	})
+}
+
+// Writes to report fields, passed by forEach with their Go types, which are
+// not declared, as Go source of struct fields, and declared fields, which
+// have other types
+func (g *generator) reportFields(typeName string, declaredFields map[string]string, forEach func(check func(owner string, f *SpecField, forceOmitempty bool, goType string))) {
+	missing := &bytes.Buffer{}
+	mismatched := &bytes.Buffer{}
+	checked := map[string]bool{}
+
+	buf := g.buf
+	g.buf = missing
+	forEach(func(owner string, f *SpecField, forceOmitempty bool, goType string) {
+		if checked[f.Name] {
+			return
+		}
+		checked[f.Name] = true
+
+		declaredType, ok := declaredFields[f.Name]
+		if !ok {
+			g.writeField(owner, f, forceOmitempty)
+		} else if declaredType != goType {
+			fmt.Fprintf(mismatched, "\t%v is %v, want %v (%v)\n", f.Name, declaredType, goType, strings.Join(f.Types, " or "))
+		}
+	})
+	g.buf = buf
+
+	if missing.Len() != 0 {
+		fmt.Fprintf(g.report, "%v is missing fields of %v:\n%v\n", typeName, g.spec.Version, missing)
+	}
+	if mismatched.Len() != 0 {
+		fmt.Fprintf(g.report, "%v has fields of other types than in %v:\n%v\n", typeName, g.spec.Version, mismatched)
+	}
+}
+
+// Reports declared API method, which result type differs from spec
+func (g *generator) reportResultType(m *SpecMethod) {
+	name := "API." + goName(m.Name)
+	declaredType, ok := g.declaredResults[name]
+	if !ok {
+		return
+	}
+
+	resultType, _ := g.methodResult(m)
+	if declaredType != resultType {
+		if declaredType == "" {
+			declaredType = "nothing"
+		}
+		if resultType == "" {
+			resultType = "nothing"
+		}
+
+		fmt.Fprintf(g.report, "%v returns %v, want %v (%v)\n\n", name, declaredType, resultType, strings.Join(m.Returns, " or "))
+	}
+}
+
+func (g *generator) writeType(t *SpecType) {
+	g.writeComment("", append(t.Description, t.Href)...)
+
+	fmt.Fprintf(g.buf, "type %v struct {\n", goTypeName(t.Name))
+	for _, f := range t.Fields {
+		g.writeField(t.Name, f, false)
+	}
+	g.buf.WriteString("}\n\n")
+}
+
+var discriminatorValueRegexp = regexp.MustCompile(`(?:must be|always) [“"]?([a-z_]+)[”"]?`)
+
+// Writes union type as a single struct with fields of all its subtypes, like
+// other union types of this library. Field, which value tells subtype, gets
+// its own string type with constants.
+func (g *generator) writeUnionType(t *SpecType) {
+	subtypes := g.subtypesOf(t)
+
+	if len(subtypes) == 0 {
+		fmt.Fprintf(g.report, "%v: subtypes of union type are missing from spec, it is generated with its own fields only\n\n", t.Name)
+		g.writeType(t)
+		return
+	}
+
+	description := append([]string(nil), t.Description...)
+	oneOf := "It should be one of"
+	for _, subtype := range subtypes {
+		subtypeDescription := ""
+		if len(subtype.Description) != 0 {
+			subtypeDescription = " - " + subtype.Description[0]
+		}
+		oneOf += "\n  " + subtype.Name + subtypeDescription
+	}
+
+	g.writeComment("", description...)
+	g.buf.WriteString("//\n")
+	for _, line := range strings.Split(oneOf, "\n") {
+		g.buf.WriteString("// " + line + "\n")
+	}
+	g.buf.WriteString("//\n")
+	g.buf.WriteString("// " + t.Href + "\n")
+	for _, subtype := range subtypes {
+		g.buf.WriteString("// " + subtype.Href + "\n")
+	}
+
+	fmt.Fprintf(g.buf, "type %v struct {\n", goTypeName(t.Name))
+
+	subtypeNames := []string{}
+	for _, subtype := range subtypes {
+		subtypeNames = append(subtypeNames, subtype.Name)
+	}
+	g.writeComment("\t", strings.Join(subtypeNames, ", "))
+	g.buf.WriteString("\n")
+
+	written := map[string]bool{}
+	enums := [][]string{}
+
+	// Fields of all subtypes
+	for _, f := range subtypes[0].Fields {
+		if !fieldInAllTypes(f.Name, subtypes) {
+			continue
+		}
+		written[f.Name] = true
+
+		values := discriminatorValues(f.Name, subtypes)
+		if values == nil {
+			g.writeField(t.Name, f, false)
+			continue
+		}
+
+		enumType := t.Name + goName(f.Name)
+		description := discriminatorValueRegexp.ReplaceAllString(f.Description, "")
+		description = strings.TrimRight(strings.TrimSpace(description), ",")
+		g.writeComment("\t", description)
+		for i, subtype := range subtypes {
+			fmt.Fprintf(g.buf, "\t//   %v - must be %v\n", subtype.Name, values[i])
+		}
+		fmt.Fprintf(g.buf, "\t%v %v `json:\"%v\"`\n", goName(f.Name), enumType, f.Name)
+
+		enums = append(enums, append([]string{enumType}, values...))
+	}
+
+	// Fields of some subtypes, grouped by the first subtype having them
+	for _, subtype := range subtypes {
+		fields := []*SpecField{}
+		for _, f := range subtype.Fields {
+			if !written[f.Name] {
+				written[f.Name] = true
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		g.buf.WriteString("\n")
+		g.writeComment("\t", subtype.Name)
+		g.buf.WriteString("\n")
+		for _, f := range fields {
+			g.writeField(subtype.Name, f, true)
+		}
+	}
+
+	g.buf.WriteString("}\n\n")
+
+	for _, enum := range enums {
+		g.writeEnumType(enum[0], enum[1:])
+	}
+}
+
+// Returns subtypes of union type, which spec has
+func (g *generator) subtypesOf(t *SpecType) []*SpecType {
+	subtypes := []*SpecType{}
+	for _, name := range t.Subtypes {
+		if subtype := g.types[name]; subtype != nil {
+			subtypes = append(subtypes, subtype)
+		}
+	}
+
+	return subtypes
+}
+
+// Returns values of field, which tells subtype, in order of subtypes, or nil
+// if some subtype doesn't tell its value
+func discriminatorValues(fieldName string, subtypes []*SpecType) []string {
+	values := []string{}
+	for _, subtype := range subtypes {
+		for _, f := range subtype.Fields {
+			if m := discriminatorValueRegexp.FindStringSubmatch(f.Description); f.Name == fieldName && m != nil {
+				values = append(values, m[1])
+			}
+		}
+	}
+
+	if len(values) != len(subtypes) {
+		return nil
+	}
+
+	return values
+}
+
+func fieldInAllTypes(fieldName string, types []*SpecType) bool {
+	for _, t := range types {
+		found := false
+		for _, f := range t.Fields {
+			if f.Name == fieldName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (g *generator) writeEnumType(enumType string, values []string) {
+	if g.declared[enumType] {
+		return
+	}
+
+	fmt.Fprintf(g.buf, "type %v string\n\nconst (\n", enumType)
+	seen := map[string]bool{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			fmt.Fprintf(g.buf, "\t%v%v %v = %q\n", enumType, goName(value), enumType, value)
+		}
+	}
+	g.buf.WriteString(")\n\n")
+}
+
+// Writes struct field, owner is a name of type or method, which has the field
+func (g *generator) writeField(owner string, f *SpecField, forceOmitempty bool) {
+	description := f.Description
+	optional := !f.Required || strings.HasPrefix(description, "Optional.")
+	if optional && !strings.HasPrefix(description, "Optional.") {
+		description = "Optional. " + description
+	}
+
+	tag := f.Name
+	if optional || forceOmitempty {
+		tag += ",omitempty"
+	}
+
+	g.writeComment("\t", description)
+	fmt.Fprintf(g.buf, "\t%v %v `json:\"%v\"`\n", goName(f.Name), g.goType(owner, f), tag)
+}
+
+// Returns Go type of field
+func (g *generator) goType(owner string, f *SpecField) string {
+	if goType, ok := typeFieldTypeOverrides[owner+"."+f.Name]; ok {
+		return goType
+	}
+
+	if goType := g.goTypeOfSet(f.Types); goType != "" {
+		return goType
+	}
+
+	if len(f.Types) == 1 {
+		if goType, ok := fieldTypeOverrides[f.Name]; ok {
+			return goType
+		}
+	}
+
+	return g.goTypeOf(f.Types[0])
+}
+
+// Returns Go type, representing all types, or empty string
+func (g *generator) goTypeOfSet(types []string) string {
+	if len(types) < 2 {
+		return ""
+	}
+
+	for _, typeSet := range typeSetNames {
+		if sameTypes(types, typeSet.types) {
+			return typeSet.goType
+		}
+	}
+
+	// Subtypes of the same union type
+	if subtype := g.types[types[0]]; subtype != nil && len(subtype.SubtypeOf) != 0 {
+		union := subtype.SubtypeOf[0]
+		for _, t := range types {
+			if st := g.types[t]; st == nil || len(st.SubtypeOf) == 0 || st.SubtypeOf[0] != union {
+				return ""
+			}
+		}
+
+		return g.goTypeOf(union)
+	}
+
+	return ""
+}
+
+func sameTypes(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for _, t := range a {
+		found := false
+		for _, t2 := range b {
+			if t == t2 {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns Go type of spec type
+func (g *generator) goTypeOf(specType string) string {
+	if strings.HasPrefix(specType, "Array of ") {
+		return "[]" + g.goTypeOf(strings.TrimPrefix(specType, "Array of "))
+	}
+
+	switch specType {
+	case "Integer", "Int":
+		return "int"
+	case "Float", "Float number":
+		return "float64"
+	case "String":
+		return "string"
+	case "Boolean", "True", "False":
+		return "bool"
+	}
+
+	if interfaceTypes[specType] {
+		return specType
+	}
+
+	// Subtypes are represented by their union type
+	if t := g.types[specType]; t != nil && len(t.SubtypeOf) != 0 && !interfaceTypes[t.SubtypeOf[0]] {
+		return "*" + goTypeName(t.SubtypeOf[0])
+	}
+
+	return "*" + goTypeName(specType)
+}
+
+func (g *generator) writeMethod(m *SpecMethod) {
+	methodName := goName(m.Name)
+	paramsType := methodName + "Params"
+	hasParams := len(m.Fields) != 0
+
+	hasChatID := false
+	hasInlineMessageID := false
+	for _, f := range m.Fields {
+		hasChatID = hasChatID || f.Name == "chat_id"
+		hasInlineMessageID = hasInlineMessageID || f.Name == "inline_message_id"
+	}
+
+	if hasParams && !g.declared[paramsType] {
+		fmt.Fprintf(g.buf, "type %v struct {\n", paramsType)
+		for _, f := range m.Fields {
+			g.writeField(m.Name, f, false)
+		}
+		g.buf.WriteString("}\n\n")
+	}
+
+	g.writeComment("", append(m.Description, m.Href)...)
+
+	resultType, resultVar := g.methodResult(m)
+
+	paramsArg, paramsValue := "", "nil"
+	if hasParams {
+		paramsArg, paramsValue = "params *"+paramsType, "params"
+	}
+
+	inputFilesPrelude, inputFiles := g.methodInputFiles(m)
+
+	if resultType == "" {
+		fmt.Fprintf(g.buf, "func (api *API) %v(%v) error {\n", methodName, paramsArg)
+	} else {
+		fmt.Fprintf(g.buf, "func (api *API) %v(%v) (%v, error) {\n", methodName, paramsArg, resultType)
+	}
+
+	g.buf.WriteString(inputFilesPrelude)
+
+	resultDest := "nil"
+	returnErr := "return fmt.Errorf(\"%v: %%w\", err)"
+	returnResult := "return nil"
+	switch {
+	case resultType == "":
+	case strings.HasPrefix(resultType, "*") && hasInlineMessageID && len(m.Returns) > 1:
+		// Result is only returned for messages, which are not inline
+		fmt.Fprintf(g.buf, "\tvar %v %v\n\n\tif params.InlineMessageID == \"\" {\n\t\t%v = &%v{}\n\t}\n\n", resultVar, resultType, resultVar, resultType[1:])
+		resultDest = resultVar
+		returnErr = "return nil, fmt.Errorf(\"%v: %%w\", err)"
+		returnResult = "return " + resultVar + ", nil"
+	case strings.HasPrefix(resultType, "*"):
+		fmt.Fprintf(g.buf, "\t%v := &%v{}\n\n", resultVar, resultType[1:])
+		resultDest = resultVar
+		returnErr = "return nil, fmt.Errorf(\"%v: %%w\", err)"
+		returnResult = "return " + resultVar + ", nil"
+	case strings.HasPrefix(resultType, "[]"):
+		fmt.Fprintf(g.buf, "\t%v := %v{}\n\n", resultVar, resultType)
+		resultDest = "&" + resultVar
+		returnErr = "return nil, fmt.Errorf(\"%v: %%w\", err)"
+		returnResult = "return " + resultVar + ", nil"
+	default:
+		fmt.Fprintf(g.buf, "\tvar %v %v\n\n", resultVar, resultType)
+		resultDest = "&" + resultVar
+		returnErr = fmt.Sprintf("return %v, fmt.Errorf(\"%%v: %%%%w\", err)", zeroValue(resultType))
+		returnResult = "return " + resultVar + ", nil"
+	}
+	returnErr = fmt.Sprintf(returnErr, methodName)
+
+	call := fmt.Sprintf("api.makeAPICall(%q, %v, %v, %v)", m.Name, paramsValue, inputFiles, resultDest)
+
+	if hasChatID {
+		fmt.Fprintf(g.buf, `	migrateToChatID, err := %v
+	if err != nil {
+		if migrateToChatID != 0 {
+			params.ChatID = migrateToChatID
+			_, err = %v
+			if err != nil {
+				%v
+			}
+		} else {
+			%v
+		}
+	}
+
+	%v
+}
+
+`, call, call, returnErr, returnErr, returnResult)
+	} else {
+		fmt.Fprintf(g.buf, `	_, err := %v
+	if err != nil {
+		%v
+	}
+
+	%v
+}
+
+`, call, returnErr, returnResult)
+	}
+}
+
+// Returns Go type of method result, empty if method returns only True, and
+// name of variable for result
+func (g *generator) methodResult(m *SpecMethod) (resultType string, resultVar string) {
+	for _, specType := range m.Returns {
+		if specType == "True" {
+			continue
+		}
+
+		resultType = g.goTypeOfSet(m.Returns)
+		if resultType == "" {
+			resultType = g.goTypeOf(specType)
+		}
+
+		switch {
+		case strings.HasPrefix(resultType, "[]*"):
+			resultVar = goVarName(resultType[3:]) + "s"
+		case strings.HasPrefix(resultType, "*"):
+			resultVar = goVarName(resultType[1:])
+		default:
+			resultVar = "result"
+		}
+
+		return resultType, resultVar
+	}
+
+	return "", ""
+}
+
+func zeroValue(goType string) string {
+	switch goType {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	default:
+		return "0"
+	}
+}
+
+// Returns statements, preparing files of method, and expression with them
+func (g *generator) methodInputFiles(m *SpecMethod) (prelude string, inputFiles string) {
+	direct := []string{}
+	loops := []string{}
+
+	for _, f := range m.Fields {
+		goType := g.goType(m.Name, f)
+		fieldExpr := "params." + goName(f.Name)
+
+		if goType == "InputFile" {
+			direct = append(direct, fieldExpr)
+			continue
+		}
+
+		isSlice := strings.HasPrefix(goType, "[]")
+		elemType := strings.TrimPrefix(goType, "[]")
+		nestedFiles := g.inputFileFields(strings.TrimPrefix(elemType, "*"))
+		if len(nestedFiles) == 0 {
+			continue
+		}
+
+		switch {
+		case isSlice:
+			elemVar := goVarName(strings.TrimPrefix(elemType, "*"))
+			exprs := []string{}
+			for _, name := range nestedFiles {
+				exprs = append(exprs, elemVar+"."+name)
+			}
+			loops = append(loops, fmt.Sprintf("\tfor _, %v := range %v {\n\t\tinputFiles = append(inputFiles, %v)\n\t}\n", elemVar, fieldExpr, strings.Join(exprs, ", ")))
+		case f.Required:
+			for _, name := range nestedFiles {
+				direct = append(direct, fieldExpr+"."+name)
+			}
+		default:
+			exprs := []string{}
+			for _, name := range nestedFiles {
+				exprs = append(exprs, fieldExpr+"."+name)
+			}
+			loops = append(loops, fmt.Sprintf("\tif %v != nil {\n\t\tinputFiles = append(inputFiles, %v)\n\t}\n", fieldExpr, strings.Join(exprs, ", ")))
+		}
+	}
+
+	if len(loops) == 0 {
+		if len(direct) == 0 {
+			return "", "nil"
+		}
+
+		return "", "[]InputFile{" + strings.Join(direct, ", ") + "}"
+	}
+
+	prelude = "\tinputFiles := []InputFile{" + strings.Join(direct, ", ") + "}\n"
+	prelude += strings.Join(loops, "") + "\n"
+
+	return prelude, "inputFiles"
+}
+
+// Returns Go names of InputFile fields of type, including fields of subtypes
+func (g *generator) inputFileFields(typeName string) []string {
+	t := g.types[typeName]
+	if t == nil {
+		return nil
+	}
+
+	types := []*SpecType{t}
+	for _, name := range t.Subtypes {
+		if subtype := g.types[name]; subtype != nil {
+			types = append(types, subtype)
+		}
+	}
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, t := range types {
+		for _, f := range t.Fields {
+			if !seen[f.Name] && g.goType(t.Name, f) == "InputFile" {
+				seen[f.Name] = true
+				names = append(names, goName(f.Name))
+			}
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,193 @@
+// Command telegrambot-gen generates types and methods of telegrambot package
+// from Bot API specification in JSON format (see Spec).
+//
+// Types are generated as structs, union types as a single struct with fields
+// of all subtypes and string type with constants for the field telling
+// subtype. Methods are generated as Params structs and API methods, which
+// repeat request on chat migration and upload InputFile fields, including ones
+// in nested structs and slices.
+//
+// With -skip-declared, types and methods already declared in the package are
+// not generated, so that only ones added in the new Bot API version are
+// written to -out. The output file itself is not considered, so running
+// generator again regenerates it. Fields of spec, which declared types and
+// Params structs don't have, are reported to stderr as generated fields, to
+// add them by hand. Declared fields and results of API methods, which types
+// differ from the ones generator would write, are reported too.
+//
+// Without -skip-declared, all types and methods of spec are generated, but
+// output is not self-contained: it uses hand-written InputFile with FileID
+// and FileReader implementing it, identifier types (e.x. ChatID and UserID),
+// ReplyMarkup and API with its makeAPICall method. So it compiles only in the
+// package next to them, instead of files declaring the same types and
+// methods.
+//
+// api.json is the snapshot of spec of the Bot API version, which the package
+// covers, and go generate runs generator on it. To update to the new Bot API
+// version, replace api.json with the new version of spec, and run
+//
+//	go generate github.com/nickname76/telegrambot
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	specPath := flag.String("spec", "api.json", "path to Bot API specification in JSON format")
+	outPath := flag.String("out", "", "path to output Go file, stdout if empty")
+	pkg := flag.String("package", "telegrambot", "package name of generated file")
+	skipDeclaredDir := flag.String("skip-declared", "", "directory of package, which types and methods are not generated")
+	flag.Parse()
+
+	spec, err := loadSpec(*specPath)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	decls := newDeclarations()
+	if *skipDeclaredDir != "" {
+		decls, err = loadDeclarations(*skipDeclaredDir, *outPath)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	g := newGenerator(spec, decls)
+
+	src, genErr := g.generate(*pkg)
+
+	os.Stderr.Write(g.report.Bytes())
+
+	// Unformatted source is written anyway, to find the error in it
+	if *outPath == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*outPath, src, 0o644)
+	}
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if genErr != nil {
+		log.Fatalf("Error: %v", genErr)
+	}
+}
+
+// Identifiers declared in target package
+type declarations struct {
+	// Names of types and API methods (as "API.Name")
+	identifiers map[string]bool
+	// Go types of fields of structs by type name and JSON name of field
+	fields map[string]map[string]string
+	// Go types of results of API methods by method name (as "API.Name"),
+	// empty if method returns only error
+	results map[string]string
+}
+
+func newDeclarations() *declarations {
+	return &declarations{
+		identifiers: map[string]bool{},
+		fields:      map[string]map[string]string{},
+		results:     map[string]string{},
+	}
+}
+
+// Returns types, struct fields and API methods declared in package at dir,
+// ignoring file at skipPath and tests
+func loadDeclarations(dir string, skipPath string) (*declarations, error) {
+	skipAbsPath := ""
+	if skipPath != "" {
+		var err error
+		skipAbsPath, err = filepath.Abs(skipPath)
+		if err != nil {
+			return nil, fmt.Errorf("loadDeclarations: %w", err)
+		}
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		absPath, err := filepath.Abs(filepath.Join(dir, fi.Name()))
+		return err == nil && absPath != skipAbsPath && !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("loadDeclarations: %w", err)
+	}
+
+	decls := newDeclarations()
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				switch decl := decl.(type) {
+				case *ast.GenDecl:
+					for _, spec := range decl.Specs {
+						if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+							decls.identifiers[typeSpec.Name.Name] = true
+
+							if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+								decls.fields[typeSpec.Name.Name] = jsonFieldTypes(structType)
+							}
+						}
+					}
+				case *ast.FuncDecl:
+					if decl.Recv != nil && len(decl.Recv.List) == 1 {
+						if star, ok := decl.Recv.List[0].Type.(*ast.StarExpr); ok {
+							if ident, ok := star.X.(*ast.Ident); ok && ident.Name == "API" {
+								name := "API." + decl.Name.Name
+								decls.identifiers[name] = true
+								decls.results[name] = resultType(decl.Type)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return decls, nil
+}
+
+// Returns Go types of struct fields by their names in JSON tags
+func jsonFieldTypes(structType *ast.StructType) map[string]string {
+	fieldTypes := map[string]string{}
+
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+
+		name := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fieldTypes[name] = types.ExprString(field.Type)
+		}
+	}
+
+	return fieldTypes
+}
+
+// Returns Go type of the first result of function, which is followed by
+// error, or empty string if function returns only error
+func resultType(funcType *ast.FuncType) string {
+	if funcType.Results == nil || len(funcType.Results.List) < 2 {
+		return ""
+	}
+
+	return types.ExprString(funcType.Results.List[0].Type)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parts of names, which are written in upper case in Go
+var initialisms = map[string]string{
+	"id":    "ID",
+	"ids":   "IDs",
+	"url":   "URL",
+	"ip":    "IP",
+	"http":  "HTTP",
+	"https": "HTTPS",
+	"json":  "JSON",
+	"html":  "HTML",
+	"api":   "API",
+}
+
+// Converts snake_case field name or camelCase method name to Go exported name
+func goName(name string) string {
+	b := &strings.Builder{}
+
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+
+		if initialism, ok := initialisms[part]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(part)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(part[size:])
+	}
+
+	return b.String()
+}
+
+// Converts Go exported name to unexported one, used for variables
+func goVarName(name string) string {
+	// Leading initialism is lowered entirely, e.x. URLInfo -> urlInfo
+	upper := 0
+	for upper < len(name) && unicode.IsUpper(rune(name[upper])) {
+		upper++
+	}
+	if upper > 1 && upper < len(name) {
+		upper--
+	}
+	if upper == 0 {
+		return name
+	}
+
+	return strings.ToLower(name[:upper]) + name[upper:]
+}
+
+// Go names of spec types, which differ from spec names
+var typeNameOverrides = map[string]string{
+	// MessageID is already the type of message identifiers
+	"MessageId": "MessageIDObject",
+}
+
+// Returns Go name of spec type
+func goTypeName(specType string) string {
+	if goType, ok := typeNameOverrides[specType]; ok {
+		return goType
+	}
+
+	return specType
+}
+
+// Go types of fields by field name, used instead of types from spec, so that
+// generated code uses identifier types of this library
+var fieldTypeOverrides = map[string]string{
+	"chat_id":                 "ChatID",
+	"from_chat_id":            "ChatID",
+	"sender_chat_id":          "ChatID",
+	"linked_chat_id":          "ChatID",
+	"migrate_to_chat_id":      "ChatID",
+	"migrate_from_chat_id":    "ChatID",
+	"user_id":                 "UserID",
+	"message_id":              "MessageID",
+	"reply_to_message_id":     "MessageID",
+	"forward_from_message_id": "MessageID",
+	"pinned_message_id":       "MessageID",
+	"inline_message_id":       "InlineMessageID",
+	"file_id":                 "FileID",
+	"file_unique_id":          "FileUniqueID",
+	"parse_mode":              "ParseMode",
+	"username":                "Username",
+	"language_code":           "LanguageCode",
+	"callback_query_id":       "CallbackQueryID",
+	"inline_query_id":         "InlineQueryID",
+	"shipping_query_id":       "ShippingQueryID",
+	"pre_checkout_query_id":   "PreCheckoutQueryID",
+	"web_app_query_id":        "WebAppQueryID",
+	"update_id":               "UpdateID",
+	"poll_id":                 "PollID",
+	"custom_emoji_id":         "CustomEmojiID",
+	"custom_emoji_ids":        "[]CustomEmojiID",
+	"chat_instance":           "ChatInstance",
+	"game_short_name":         "GameShortName",
+	"small_file_id":           "FileID",
+	"big_file_id":             "FileID",
+	"photo_file_id":           "FileID",
+	"gif_file_id":             "FileID",
+	"mpeg4_file_id":           "FileID",
+	"sticker_file_id":         "FileID",
+	"document_file_id":        "FileID",
+	"video_file_id":           "FileID",
+	"voice_file_id":           "FileID",
+	"audio_file_id":           "FileID",
+	"small_file_unique_id":    "FileUniqueID",
+	"big_file_unique_id":      "FileUniqueID",
+	"shipping_option_id":      "ShippingOptionID",
+	"result_id":               "InlineQueryResultID",
+	"set_name":                "StickerSetName",
+	"sticker_type":            "StickerType",
+	"explanation_parse_mode":  "ParseMode",
+	"bot_username":            "Username",
+	// Sizes and Unix times may not fit in int on 32-bit platforms
+	"file_size":                       "int64",
+	"date":                            "int64",
+	"forward_date":                    "int64",
+	"edit_date":                       "int64",
+	"close_date":                      "int64",
+	"start_date":                      "int64",
+	"expire_date":                     "int64",
+	"until_date":                      "int64",
+	"file_date":                       "int64",
+	"last_error_date":                 "int64",
+	"last_synchronization_error_date": "int64",
+}
+
+// Go types of fields by type or method name and field name, take precedence
+// over fieldTypeOverrides
+var typeFieldTypeOverrides = map[string]string{
+	"User.id":                            "UserID",
+	"Chat.id":                            "ChatID",
+	"Chat.type":                          "ChatType",
+	"Poll.id":                            "PollID",
+	"Poll.type":                          "PollType",
+	"CallbackQuery.id":                   "CallbackQueryID",
+	"InlineQuery.id":                     "InlineQueryID",
+	"InlineQuery.chat_type":              "InlineQueryChatType",
+	"ShippingOption.id":                  "ShippingOptionID",
+	"ShippingQuery.id":                   "ShippingQueryID",
+	"PreCheckoutQuery.id":                "PreCheckoutQueryID",
+	"MessageEntity.type":                 "MessageEntityType",
+	"Sticker.type":                       "StickerType",
+	"StickerSet.name":                    "StickerSetName",
+	"MaskPosition.point":                 "MaskPositionPoint",
+	"sendDice.emoji":                     "DiceEmoji",
+	"sendChatAction.action":              "ChatAction",
+	"sendPoll.type":                      "PollType",
+	"KeyboardButtonPollType.type":        "PollType",
+	"ChatMember.status":                  "ChatMemberStatus",
+	"BotCommandScope.type":               "BotCommandScopeType",
+	"MenuButton.type":                    "MenuButtonType",
+	"InputMedia.type":                    "InputMediaType",
+	"InlineQueryResult.type":             "InlineQueryResultType",
+	"InlineQueryResult.id":               "InlineQueryResultID",
+	"EncryptedPassportElement.type":      "PassportElementType",
+	"PassportElementError.source":        "PassportElementErrorSource",
+	"PassportElementError.type":          "PassportElementType",
+	"getStickerSet.name":                 "StickerSetName",
+	"createNewStickerSet.name":           "StickerSetName",
+	"addStickerToSet.name":               "StickerSetName",
+	"setStickerSetThumb.name":            "StickerSetName",
+	"setChatStickerSet.sticker_set_name": "StickerSetName",
+	"setStickerPositionInSet.sticker":    "FileID",
+	"deleteStickerFromSet.sticker":       "FileID",
+	"WebhookInfo.allowed_updates":        "[]UpdateType",
+	"getUpdates.offset":                  "UpdateID",
+	"getUpdates.allowed_updates":         "[]UpdateType",
+	"setWebhook.allowed_updates":         "[]UpdateType",
+}
+
+// Union types of spec, which are Go interfaces in this library instead of
+// merged structs
+var interfaceTypes = map[string]bool{
+	"InputFile":   true,
+	"ReplyMarkup": true,
+}
+
+// Sets of types, which are represented by a single Go type
+var typeSetNames = []struct {
+	types  []string
+	goType string
+}{
+	{[]string{"Integer", "String"}, "ChatIDOrUsername"},
+	{[]string{"InputFile", "String"}, "InputFile"},
+	{[]string{"InlineKeyboardMarkup", "ReplyKeyboardMarkup", "ReplyKeyboardRemove", "ForceReply"}, "ReplyMarkup"},
+}
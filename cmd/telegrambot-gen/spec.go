@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Bot API specification in JSON format, scraped from
+// https://core.telegram.org/bots/api
+//
+//	{
+//		"version": "Bot API 6.2",
+//		"methods": {
+//			"getMe": {"name": "getMe", "href": "...", "description": ["..."], "returns": ["User"]},
+//			...
+//		},
+//		"types": {
+//			"User": {"name": "User", "href": "...", "description": ["..."], "fields": [...]},
+//			"MenuButton": {"name": "MenuButton", "subtypes": ["MenuButtonCommands", ...]},
+//			...
+//		}
+//	}
+type Spec struct {
+	Version string                   `json:"version"`
+	Methods specEntries[*SpecMethod] `json:"methods"`
+	Types   specEntries[*SpecType]   `json:"types"`
+}
+
+type SpecMethod struct {
+	Name        string       `json:"name"`
+	Href        string       `json:"href"`
+	Description []string     `json:"description"`
+	Returns     []string     `json:"returns"`
+	Fields      []*SpecField `json:"fields"`
+}
+
+type SpecType struct {
+	Name        string       `json:"name"`
+	Href        string       `json:"href"`
+	Description []string     `json:"description"`
+	Fields      []*SpecField `json:"fields"`
+	// Types, which this type may be, if it is union type
+	Subtypes []string `json:"subtypes"`
+	// Union types, which this type is part of
+	SubtypeOf []string `json:"subtype_of"`
+}
+
+type SpecField struct {
+	Name string `json:"name"`
+	// Types of field, e.x. ["Integer", "String"] or ["Array of PhotoSize"]
+	Types       []string `json:"types"`
+	Required    bool     `json:"required"`
+	Description string   `json:"description"`
+}
+
+// Values of JSON object in the order they appear in spec, which is the order
+// of the documentation
+type specEntries[T any] []T
+
+func (entries *specEntries[T]) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected object, got %v", tok)
+	}
+
+	for dec.More() {
+		// Key duplicates name of entry
+		_, err = dec.Token()
+		if err != nil {
+			return err
+		}
+
+		var entry T
+		err = dec.Decode(&entry)
+		if err != nil {
+			return err
+		}
+
+		*entries = append(*entries, entry)
+	}
+
+	return nil
+}
+
+func loadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loadSpec: %w", err)
+	}
+
+	spec := &Spec{}
+	err = json.Unmarshal(data, spec)
+	if err != nil {
+		return nil, fmt.Errorf("loadSpec: %w", err)
+	}
+
+	return spec, nil
+}
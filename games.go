@@ -115,7 +115,7 @@ type SetGameScoreParams struct {
 func (api *API) SetGameScore(params *SetGameScoreParams) (*Message, error) {
 	var msg *Message
 
-	if params.InlineMessageID != "" {
+	if params.InlineMessageID == "" {
 		msg = &Message{}
 	}
 
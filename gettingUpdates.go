@@ -64,6 +64,9 @@ type Update struct {
 	// can_invite_users administrator right in the chat to receive these
 	// updates.
 	ChatJoinRequest *ChatJoinRequest `json:"chat_join_request,omitempty"`
+
+	// JSON, from which update was decoded, see RawJSON
+	raw []byte
 }
 
 type GetUpdatesParams struct {
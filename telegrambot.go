@@ -2,12 +2,16 @@ package telegrambot
 
 // https://core.telegram.org/bots/api
 
+//go:generate go run ./cmd/telegrambot-gen -spec cmd/telegrambot-gen/api.json -skip-declared . -out generated.go
+
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"reflect"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
@@ -25,6 +29,44 @@ var defaultFasthttpClient = &fasthttp.Client{
 
 // Default function for performing http requests by API
 func DefaultHttpDoRequest(method string, url string, headers map[string]string, body []byte) (respBody []byte, err error) {
+	respBody, err = doFasthttpRequest(context.Background(), method, url, headers, func(req *fasthttp.Request) {
+		req.SetBody(body)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DefaultHttpDoRequest: %w", err)
+	}
+
+	return respBody, nil
+}
+
+// Default function for performing http requests by API, which respects
+// cancellation and deadline of ctx
+func DefaultHttpDoRequestContext(ctx context.Context, method string, url string, headers map[string]string, body []byte) (respBody []byte, err error) {
+	respBody, err = doFasthttpRequest(ctx, method, url, headers, func(req *fasthttp.Request) {
+		req.SetBody(body)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DefaultHttpDoRequestContext: %w", err)
+	}
+
+	return respBody, nil
+}
+
+// Default function for performing http requests with streamed body by API,
+// used for uploading files. Body is sent with chunked transfer encoding, if
+// bodySize is -1.
+func DefaultHttpDoStreamRequest(ctx context.Context, method string, url string, headers map[string]string, body io.Reader, bodySize int64) (respBody []byte, err error) {
+	respBody, err = doFasthttpRequest(ctx, method, url, headers, func(req *fasthttp.Request) {
+		req.SetBodyStream(body, int(bodySize))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DefaultHttpDoStreamRequest: %w", err)
+	}
+
+	return respBody, nil
+}
+
+func doFasthttpRequest(ctx context.Context, method string, url string, headers map[string]string, setBody func(req *fasthttp.Request)) (respBody []byte, err error) {
 	req := &fasthttp.Request{}
 
 	req.Header.SetMethod(method)
@@ -34,13 +76,36 @@ func DefaultHttpDoRequest(method string, url string, headers map[string]string,
 		req.Header.Set(k, v)
 	}
 
-	req.SetBody(body)
+	setBody(req)
 
 	resp := &fasthttp.Response{}
 
-	err = defaultFasthttpClient.Do(req, resp)
+	do := func() error {
+		if deadline, ok := ctx.Deadline(); ok {
+			return defaultFasthttpClient.DoDeadline(req, resp, deadline)
+		}
+
+		return defaultFasthttpClient.Do(req, resp)
+	}
+
+	if ctx.Done() == nil {
+		err = do()
+	} else {
+		// fasthttp can not abort request in progress, so request is left to
+		// complete in background, when ctx is done
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- do()
+		}()
+
+		select {
+		case err = <-errCh:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("DefaultHttpDoRequest: %w", err)
+		return nil, err
 	}
 
 	respBody = resp.Body()
@@ -53,6 +118,44 @@ type API struct {
 	Token         string
 	EndpointURL   string
 	HttpDoRequest func(method string, url string, headers map[string]string, body []byte) (respBody []byte, err error)
+	// Optional. Context aware version of HttpDoRequest, takes precedence over
+	// HttpDoRequest when set. ctx is the one passed to WithContext. When not
+	// set, DefaultHttpDoRequest is replaced with DefaultHttpDoRequestContext.
+	HttpDoRequestContext func(ctx context.Context, method string, url string, headers map[string]string, body []byte) (respBody []byte, err error)
+	// Optional. Performs http requests with streamed body, used for uploading
+	// files without buffering them in memory. bodySize is -1, if size of some
+	// file reader is unknown. When not set, request body with files is
+	// buffered and passed to HttpDoRequestContext or HttpDoRequest.
+	//
+	// File readers are read only once, so requests with them are repeated by
+	// RetryPolicy only if all readers implement io.Seeker.
+	//
+	// Not set by NewAPI. Set it to DefaultHttpDoStreamRequest, or to
+	// streaming function of the same transport as HttpDoRequestContext, e.x.
+	// HttpTransport.DoStreamRequest, to stream uploads.
+	HttpDoStreamRequest func(ctx context.Context, method string, url string, headers map[string]string, body io.Reader, bodySize int64) (respBody []byte, err error)
+	// Optional. When group chat is migrated to a supergroup, automatically
+	// repeat request with the new chat identifier, which is also written to
+	// ChatID field of request params. Otherwise ChatMigratedError is returned.
+	// Requests with file readers are repeated only if all readers implement
+	// io.Seeker, as they are read again.
+	ResendOnChatMigration bool
+	// Optional. Called when request fails because group chat was migrated to a
+	// supergroup, e.x. to update stored chat identifiers. oldChatID is zero, if
+	// request had no numeric chat_id.
+	OnChatMigrated func(oldChatID ChatID, newChatID ChatID)
+	// Optional. Policy of repeating failed requests. By default, only requests
+	// exceeding flood control are repeated, after waiting for the time
+	// specified by Telegram, without limits.
+	RetryPolicy *RetryPolicy
+	// Optional. Limits rate of requests, every request attempt waits for it
+	// before sending. See RateLimiter for limiter following Telegram limits.
+	Limiter Limiter
+	// Optional. Interceptors of all API calls, e.x. for logging, metrics or
+	// tracing. The first interceptor is the outermost one.
+	Interceptors []Interceptor
+
+	ctx context.Context
 }
 
 // Creates Telegram Bot API interface instance. If you want to customize http
@@ -75,6 +178,43 @@ func NewAPI(token string) (*API, *User, error) {
 	return api, user, nil
 }
 
+// Returns shallow copy of api, which performs all requests with ctx. When ctx
+// is canceled or its deadline is exceeded, requests in progress and waiting for
+// flood control are aborted with ctx error.
+//
+//	msg, err := api.WithContext(ctx).SendMessage(params)
+func (api *API) WithContext(ctx context.Context) *API {
+	if ctx == nil {
+		panic("WithContext: nil context")
+	}
+
+	apiCopy := *api
+	apiCopy.ctx = ctx
+
+	return &apiCopy
+}
+
+// Returns context used by api for requests. Returned context is always
+// non-nil, it defaults to context.Background().
+func (api *API) Context() context.Context {
+	if api.ctx != nil {
+		return api.ctx
+	}
+
+	return context.Background()
+}
+
+// Returns description of api with masked token, so that printing API does not
+// leak it. Value receiver makes it apply to both API and *API.
+func (api API) String() string {
+	return fmt.Sprintf("API{Token: %v, EndpointURL: %v}", maskToken(api.Token), api.EndpointURL)
+}
+
+// Same as String, used by %#v format
+func (api API) GoString() string {
+	return fmt.Sprintf("telegrambot.API{Token: %q, EndpointURL: %q}", maskToken(api.Token), api.EndpointURL)
+}
+
 // Response on API request. Used internally by this library.
 type Response struct {
 	OK          bool   `json:"ok"`
@@ -87,6 +227,43 @@ type Response struct {
 }
 
 func (api *API) makeAPICall(method string, requestData any, inputFiles []InputFile, resultDest any) (migrateToChatID ChatID, err error) {
+	if len(api.Interceptors) == 0 {
+		return api.performAPICall(api.Context(), method, requestData, inputFiles, resultDest, nil)
+	}
+
+	call := &APICall{
+		Method:     method,
+		Params:     requestData,
+		InputFiles: inputFiles,
+		Result:     resultDest,
+	}
+
+	invoke := func(ctx context.Context) error {
+		start := time.Now()
+		migrateToChatID, err = api.performAPICall(ctx, method, requestData, inputFiles, resultDest, &call.Retries)
+		call.Latency = time.Since(start)
+
+		return err
+	}
+
+	for i := len(api.Interceptors) - 1; i >= 0; i-- {
+		interceptor, next := api.Interceptors[i], invoke
+		invoke = func(ctx context.Context) error {
+			return interceptor(ctx, call, next)
+		}
+	}
+
+	err = invoke(api.Context())
+	if err != nil {
+		return migrateToChatID, err
+	}
+
+	return 0, nil
+}
+
+// Performs API call, repeating it according to retry policy. Number of repeats
+// is written to retries, if it is not nil.
+func (api *API) performAPICall(ctx context.Context, method string, requestData any, inputFiles []InputFile, resultDest any, retries *int) (migrateToChatID ChatID, err error) {
 	var (
 		reqURL         = api.EndpointURL + api.Token + "/" + method
 		reqContentType string
@@ -104,79 +281,396 @@ func (api *API) makeAPICall(method string, requestData any, inputFiles []InputFi
 		return 0, fmt.Errorf("makeAPICall: %w", err)
 	}
 
-	if inputFilesToUpload := filterInputFilesNeedingUpload(inputFiles); len(inputFilesToUpload) == 0 {
+	// Files to upload, if request body is streamed
+	var streamedInputFiles []InputFile
+
+	inputFilesToUpload := filterInputFilesNeedingUpload(inputFiles)
+
+	// Positions of file readers are remembered before they are read, to read
+	// them again, when request is repeated
+	rewindInputFiles := newInputFilesRewinder(inputFilesToUpload)
+
+	if len(inputFilesToUpload) == 0 {
 		reqContentType = "application/json"
 		reqBody = requestDataJSON
+	} else if api.HttpDoStreamRequest != nil {
+		streamedInputFiles = inputFilesToUpload
 	} else {
 		reqBodyBuf := bytes.NewBuffer(nil)
 
 		mw := multipart.NewWriter(reqBodyBuf)
 
-		var err error
-		iter := jsoniterCfg.BorrowIterator(requestDataJSON)
-		iter.ReadMapCB(func(i *jsoniter.Iterator, s string) bool {
-			err = mw.WriteField(s, i.ReadAny().ToString())
-			return err == nil
-		})
+		err = writeMultipartBody(mw, jsoniterCfg, requestDataJSON, inputFilesToUpload, true)
 		if err != nil {
 			return 0, fmt.Errorf("makeAPICall: %w", err)
 		}
 
-		for _, inputFile := range inputFilesToUpload {
-			fieldname, filename, reader := inputFile.multipartFormFile()
-			filew, err := mw.CreateFormFile(fieldname, filename)
+		reqContentType = mw.FormDataContentType()
+		reqBody = reqBodyBuf.Bytes()
+	}
+
+	retryPolicy := api.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = legacyRetryPolicy
+	}
+
+	var totalWait time.Duration
+
+	var limiterChatID ChatIDOrUsername
+	if api.Limiter != nil {
+		limiterChatID = requestChatIDOrUsername(jsoniterCfg, requestDataJSON)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if retries != nil {
+			*retries = attempt - 1
+		}
+
+		if api.Limiter != nil {
+			err = api.Limiter.Wait(ctx, method, limiterChatID)
 			if err != nil {
 				return 0, fmt.Errorf("makeAPICall: %w", err)
 			}
+		}
 
-			_, err = io.Copy(filew, reader)
-			if err != nil {
-				return 0, fmt.Errorf("makeAPICall: %w", err)
+		migrateToChatID, err = api.doAPIRequest(ctx, jsoniterCfg, method, reqURL, reqContentType, reqBody, streamedInputFiles, requestDataJSON, resultDest)
+		if err == nil {
+			return 0, nil
+		}
+
+		wait, ok := retryPolicy.nextWait(attempt, totalWait, err)
+		if !ok {
+			// Request is resent by caller with files read again, so
+			// ChatMigratedError is returned, if they can't be
+			if migrateToChatID != 0 && rewindInputFiles() != nil {
+				return 0, err
 			}
+
+			return migrateToChatID, err
 		}
 
-		err = mw.Close()
+		// Buffered request body is sent again as is
+		if streamedInputFiles != nil && rewindInputFiles() != nil {
+			return migrateToChatID, err
+		}
+
+		if retryPolicy.OnRetry != nil {
+			retryPolicy.OnRetry(&RetryInfo{
+				Method:  method,
+				Attempt: attempt,
+				Err:     err,
+				Wait:    wait,
+			})
+		}
+
+		err = sleepContext(ctx, wait)
 		if err != nil {
 			return 0, fmt.Errorf("makeAPICall: %w", err)
 		}
 
-		reqContentType = mw.FormDataContentType()
-		reqBody = reqBodyBuf.Bytes()
+		totalWait += wait
 	}
+}
 
-loop:
-	for {
-		respBody, err := api.HttpDoRequest("POST", reqURL, map[string]string{
+// Performs single attempt of API request. Request body is streamed, if
+// streamedInputFiles is not nil, otherwise reqBody is sent.
+func (api *API) doAPIRequest(ctx context.Context, jsoniterCfg jsoniter.API, method string, reqURL string, reqContentType string, reqBody []byte, streamedInputFiles []InputFile, requestDataJSON []byte, resultDest any) (migrateToChatID ChatID, err error) {
+	var respBody []byte
+	if streamedInputFiles != nil {
+		respBody, err = api.httpDoStreamRequest(ctx, jsoniterCfg, reqURL, requestDataJSON, streamedInputFiles)
+	} else {
+		respBody, err = api.httpDoRequest(ctx, "POST", reqURL, map[string]string{
 			"Content-Type": reqContentType,
 		}, reqBody)
-		if err != nil {
-			return 0, fmt.Errorf("makeAPICall: %w", err)
+	}
+	if err != nil {
+		// Errors of http requests may contain request url with bot token
+		return 0, fmt.Errorf("makeAPICall: %w", redactError(err, api.Token))
+	}
+
+	apiResp := &Response{
+		Result: resultDest,
+	}
+
+	err = jsoniterCfg.Unmarshal(respBody, apiResp)
+	if err != nil {
+		return 0, fmt.Errorf("makeAPICall: %w", newResponseDecodeError(respBody, err))
+	}
+
+	if !apiResp.OK {
+		apiErr := &APIError{
+			Method:      method,
+			ErrorCode:   apiResp.ErrorCode,
+			Description: apiResp.Description,
+			Parameters:  apiResp.Parameters,
+		}
+
+		if apiRespParams := apiResp.Parameters; apiRespParams != nil && apiRespParams.MigrateToChatID != 0 {
+			chatMigratedErr := &ChatMigratedError{
+				OldChatID: requestChatID(jsoniterCfg, requestDataJSON),
+				NewChatID: apiRespParams.MigrateToChatID,
+				APIError:  apiErr,
+			}
+
+			if api.OnChatMigrated != nil {
+				api.OnChatMigrated(chatMigratedErr.OldChatID, chatMigratedErr.NewChatID)
+			}
+
+			// Public methods repeat request, when migrateToChatID is returned
+			if api.ResendOnChatMigration {
+				migrateToChatID = chatMigratedErr.NewChatID
+			}
+
+			return migrateToChatID, fmt.Errorf("makeAPICall: %w", chatMigratedErr)
 		}
 
-		apiResp := &Response{
-			Result: resultDest,
+		return 0, fmt.Errorf("makeAPICall - telegram bot api error: %w", apiErr)
+	}
+
+	return 0, nil
+}
+
+// Returns numeric chat_id field of request data, or zero if there is no such
+func requestChatID(jsoniterCfg jsoniter.API, requestDataJSON []byte) ChatID {
+	chatIDAny := jsoniterCfg.Get(requestDataJSON, "chat_id")
+	if chatIDAny.ValueType() != jsoniter.NumberValue {
+		return 0
+	}
+
+	return ChatID(chatIDAny.ToInt64())
+}
+
+// Returns chat_id field of request data, or nil if there is no such
+func requestChatIDOrUsername(jsoniterCfg jsoniter.API, requestDataJSON []byte) ChatIDOrUsername {
+	chatIDAny := jsoniterCfg.Get(requestDataJSON, "chat_id")
+
+	switch chatIDAny.ValueType() {
+	case jsoniter.NumberValue:
+		return ChatID(chatIDAny.ToInt64())
+	case jsoniter.StringValue:
+		return Username(chatIDAny.ToString())
+	default:
+		return nil
+	}
+}
+
+func (api *API) httpDoRequest(ctx context.Context, method string, url string, headers map[string]string, body []byte) (respBody []byte, err error) {
+	if api.HttpDoRequestContext != nil {
+		return api.HttpDoRequestContext(ctx, method, url, headers, body)
+	}
+
+	// DefaultHttpDoRequest set by NewAPI is replaced with its context aware
+	// version, custom HttpDoRequest is used as is
+	if api.HttpDoRequest == nil || isDefaultHttpDoRequest(api.HttpDoRequest) {
+		return DefaultHttpDoRequestContext(ctx, method, url, headers, body)
+	}
+
+	err = ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return api.HttpDoRequest(method, url, headers, body)
+}
+
+var defaultHttpDoRequestPointer = reflect.ValueOf(DefaultHttpDoRequest).Pointer()
+
+func isDefaultHttpDoRequest(httpDoRequest func(method string, url string, headers map[string]string, body []byte) (respBody []byte, err error)) bool {
+	return reflect.ValueOf(httpDoRequest).Pointer() == defaultHttpDoRequestPointer
+}
+
+// Streams multipart request body to HttpDoStreamRequest through pipe
+func (api *API) httpDoStreamRequest(ctx context.Context, jsoniterCfg jsoniter.API, url string, requestDataJSON []byte, inputFiles []InputFile) (respBody []byte, err error) {
+	pr, pw := io.Pipe()
+
+	mw := multipart.NewWriter(pw)
+
+	bodySize, err := multipartBodySize(jsoniterCfg, mw.Boundary(), requestDataJSON, inputFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	writeErrCh := make(chan error, 1)
+	go func() {
+		err := writeMultipartBody(mw, jsoniterCfg, requestDataJSON, inputFiles, true)
+		pw.CloseWithError(err)
+		writeErrCh <- err
+	}()
+
+	respBody, err = api.HttpDoStreamRequest(ctx, "POST", url, map[string]string{
+		"Content-Type": mw.FormDataContentType(),
+	}, pr, bodySize)
+
+	// Unblocks writing, if body was not read completely
+	pr.CloseWithError(io.ErrClosedPipe)
+	writeErr := <-writeErrCh
+
+	if err != nil {
+		// Reports reading file error instead of its consequence
+		if writeErr != nil && !errors.Is(writeErr, io.ErrClosedPipe) {
+			return nil, writeErr
 		}
 
-		err = jsoniterCfg.Unmarshal(respBody, apiResp)
+		return nil, err
+	}
+
+	return respBody, nil
+}
+
+// Writes request data fields and files to mw and closes it. File contents are
+// omitted, if withFileContents is false.
+func writeMultipartBody(mw *multipart.Writer, jsoniterCfg jsoniter.API, requestDataJSON []byte, inputFiles []InputFile, withFileContents bool) error {
+	var err error
+	iter := jsoniterCfg.BorrowIterator(requestDataJSON)
+	iter.ReadMapCB(func(i *jsoniter.Iterator, s string) bool {
+		err = mw.WriteField(s, i.ReadAny().ToString())
+		return err == nil
+	})
+	jsoniterCfg.ReturnIterator(iter)
+	if err != nil {
+		return err
+	}
+
+	for _, inputFile := range inputFiles {
+		fieldname, filename, reader := inputFile.multipartFormFile()
+		filew, err := mw.CreateFormFile(fieldname, filename)
 		if err != nil {
-			return 0, fmt.Errorf("makeAPICall: %w", err)
+			return err
 		}
 
-		if !apiResp.OK {
-			if apiRespParams := apiResp.Parameters; apiRespParams != nil {
-				switch {
-				case apiRespParams.MigrateToChatID != 0:
-					return apiRespParams.MigrateToChatID, nil
-				case apiRespParams.RetryAfter != 0:
-					time.Sleep(time.Second * time.Duration(apiRespParams.RetryAfter))
-					continue loop
-				}
+		if withFileContents {
+			_, err = io.Copy(filew, reader)
+			if err != nil {
+				return err
 			}
+		}
+	}
+
+	return mw.Close()
+}
+
+// Returns exact size of multipart body, or -1 if size of some file reader is
+// unknown
+func multipartBodySize(jsoniterCfg jsoniter.API, boundary string, requestDataJSON []byte, inputFiles []InputFile) (int64, error) {
+	var filesSize int64
+	for _, inputFile := range inputFiles {
+		_, _, reader := inputFile.multipartFormFile()
+
+		size := readerSize(reader)
+		if size < 0 {
+			return -1, nil
+		}
+
+		filesSize += size
+	}
+
+	cw := &countingWriter{}
+
+	mw := multipart.NewWriter(cw)
+	err := mw.SetBoundary(boundary)
+	if err != nil {
+		return 0, err
+	}
+
+	err = writeMultipartBody(mw, jsoniterCfg, requestDataJSON, inputFiles, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return cw.n + filesSize, nil
+}
+
+// Returns number of bytes left to read from r, or -1 if it is unknown
+func readerSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case io.Seeker:
+		cur, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+
+		_, err = r.Seek(cur, io.SeekStart)
+		if err != nil {
+			return -1
+		}
+
+		return end - cur
+	default:
+		return -1
+	}
+}
 
-			return 0, fmt.Errorf("makeAPICall - telegram bot api error: %w", errors.New(apiResp.Description))
+type countingWriter struct {
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.n += int64(len(p))
+	return len(p), nil
+}
+
+// Remembers current positions of file readers, returned function seeks them
+// back. It fails, if some reader does not implement io.Seeker.
+func newInputFilesRewinder(inputFiles []InputFile) func() error {
+	type readerOffset struct {
+		seeker io.Seeker
+		offset int64
+	}
+
+	readerOffsets := []readerOffset{}
+	rewindable := true
+
+	for _, inputFile := range inputFiles {
+		_, _, reader := inputFile.multipartFormFile()
+
+		seeker, ok := reader.(io.Seeker)
+		if !ok {
+			rewindable = false
+			break
+		}
+
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			rewindable = false
+			break
+		}
+
+		readerOffsets = append(readerOffsets, readerOffset{seeker, offset})
+	}
+
+	return func() error {
+		if !rewindable {
+			return errors.New("file reader does not implement io.Seeker")
+		}
+
+		for _, ro := range readerOffsets {
+			_, err := ro.seeker.Seek(ro.offset, io.SeekStart)
+			if err != nil {
+				return err
+			}
 		}
 
-		return 0, nil
+		return nil
+	}
+}
+
+// Sleeps for duration d, returns earlier with ctx error, if ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
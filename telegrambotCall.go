@@ -0,0 +1,86 @@
+package telegrambot
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Calls Bot API method, which may be not covered by this library yet. params
+// are encoded the same way, as params of other methods: as JSON, or as
+// multipart form, if some of files need to be uploaded. files must contain all
+// InputFile values referenced by params, including ones in nested structs (e.x.
+// InputMedia). result is decoded from JSON, it can be nil, if result is not
+// needed. Requests are repeated according to api.RetryPolicy.
+//
+// If chat was migrated to a supergroup and api.ResendOnChatMigration is set,
+// request is repeated with the new chat identifier, when params is a pointer
+// to struct with ChatID field or map[string]any.
+//
+//	topic := &struct {
+//		MessageThreadID int64 `json:"message_thread_id"`
+//	}{}
+//	err := api.Call("createForumTopic", map[string]any{
+//		"chat_id": chatID,
+//		"name":    "Topic",
+//	}, nil, topic)
+func (api *API) Call(method string, params any, files []InputFile, result any) error {
+	migrateToChatID, err := api.makeAPICall(method, params, files, result)
+	if err != nil {
+		if migrateToChatID != 0 && setParamsChatID(params, migrateToChatID) {
+			_, err = api.makeAPICall(method, params, files, result)
+			if err != nil {
+				return fmt.Errorf("Call: %w", err)
+			}
+		} else {
+			return fmt.Errorf("Call: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Same as API.Call, but returns result decoded into a value of type T
+//
+//	type forumTopic struct {
+//		MessageThreadID int64 `json:"message_thread_id"`
+//	}
+//
+//	topic, err := telegrambot.Call[*forumTopic](api, "createForumTopic", map[string]any{
+//		"chat_id": chatID,
+//		"name":    "Topic",
+//	}, nil)
+func Call[T any](api *API, method string, params any, files []InputFile) (T, error) {
+	var result T
+
+	err := api.Call(method, params, files, &result)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result, nil
+}
+
+// Sets chat identifier in params of API.Call, returns false if params do not
+// have it
+func setParamsChatID(params any, chatID ChatID) bool {
+	if m, ok := params.(map[string]any); ok {
+		m["chat_id"] = chatID
+		return true
+	}
+
+	v := reflect.ValueOf(params)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+
+	field := v.Elem().FieldByName("ChatID")
+	chatIDValue := reflect.ValueOf(chatID)
+	if !field.IsValid() || !field.CanSet() || !chatIDValue.Type().AssignableTo(field.Type()) {
+		return false
+	}
+
+	field.Set(chatIDValue)
+
+	return true
+}
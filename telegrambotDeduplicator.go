@@ -0,0 +1,214 @@
+package telegrambot
+
+import (
+	"sync"
+	"time"
+)
+
+type UpdateDeduplicatorParams struct {
+	// Optional. Number of the last received update identifiers, which are
+	// remembered to detect repeated updates. Defaults to 10000.
+	WindowSize int
+	// Optional. Maximum time update is held to pass updates to receiver in
+	// order of their UpdateID. Zero means, that updates are not reordered.
+	ReorderDelay time.Duration
+	// Optional. Received update identifiers are forgotten, if no updates were
+	// received for this time, as Telegram chooses identifier of the next
+	// update randomly after a week without updates. Defaults to 24 hours.
+	ResetAfter time.Duration
+}
+
+// Ignores repeated updates, e.x. when Telegram repeats webhook request, which
+// response was lost, and optionally restores order of updates by their
+// UpdateID.
+//
+// With ReorderDelay, update is held, until updates with lower identifiers
+// are received or delay exceeds, and receiver is called sequentially. Note,
+// that Receive returns before held update is handled, so webhook request is
+// responded before it.
+//
+//	deduplicator := telegrambot.NewUpdateDeduplicator(&telegrambot.UpdateDeduplicatorParams{
+//		ReorderDelay: time.Second,
+//	}, dispatcher.Receive)
+//	handler := telegrambot.NewWebhookHandler(nil, deduplicator.Receive)
+type UpdateDeduplicator struct {
+	receiver     func(update *Update, err error)
+	windowSize   int
+	reorderDelay time.Duration
+	resetAfter   time.Duration
+
+	mu           sync.Mutex
+	seen         map[UpdateID]struct{}
+	window       []UpdateID
+	lastReceived time.Time
+	// UpdateID of the last update passed to receiver, zero if unknown
+	lastPassed UpdateID
+	// Held updates, sorted by UpdateID, and times until they can be held
+	held      []*Update
+	deadlines map[UpdateID]time.Time
+	timer     *time.Timer
+}
+
+// Creates deduplicator, which passes updates to receiver. params can be nil.
+func NewUpdateDeduplicator(params *UpdateDeduplicatorParams, receiver func(update *Update, err error)) *UpdateDeduplicator {
+	if params == nil {
+		params = &UpdateDeduplicatorParams{}
+	}
+
+	windowSize := params.WindowSize
+	if windowSize <= 0 {
+		windowSize = 10000
+	}
+
+	resetAfter := params.ResetAfter
+	if resetAfter <= 0 {
+		resetAfter = 24 * time.Hour
+	}
+
+	return &UpdateDeduplicator{
+		receiver:     receiver,
+		windowSize:   windowSize,
+		reorderDelay: params.ReorderDelay,
+		resetAfter:   resetAfter,
+		seen:         map[UpdateID]struct{}{},
+		deadlines:    map[UpdateID]time.Time{},
+	}
+}
+
+// Passes update to receiver, if it was not received before. Errors are
+// passed to receiver immediately. Has the same signature as receiver of
+// NewWebhookHandler and StartReceivingUpdates.
+func (d *UpdateDeduplicator) Receive(update *Update, err error) {
+	if err != nil {
+		d.receiver(nil, err)
+		return
+	}
+
+	d.mu.Lock()
+
+	now := time.Now()
+	if !d.lastReceived.IsZero() && now.Sub(d.lastReceived) >= d.resetAfter {
+		d.reset()
+	}
+	d.lastReceived = now
+
+	if _, ok := d.seen[update.UpdateID]; ok {
+		d.mu.Unlock()
+		return
+	}
+	d.remember(update.UpdateID)
+
+	if d.reorderDelay <= 0 {
+		d.mu.Unlock()
+		d.receiver(update, nil)
+		return
+	}
+
+	// Receiver is called under lock to keep order of updates
+	defer d.mu.Unlock()
+
+	d.held = SortUpdates(append(d.held, update))
+	d.deadlines[update.UpdateID] = now.Add(d.reorderDelay)
+
+	d.pass(now)
+}
+
+// Forgets received updates, as sequence of identifiers is restarted
+func (d *UpdateDeduplicator) reset() {
+	d.seen = map[UpdateID]struct{}{}
+	d.window = d.window[:0]
+	d.lastPassed = 0
+}
+
+// Adds update identifier to the window, removing the oldest one, if window is
+// full
+func (d *UpdateDeduplicator) remember(updateID UpdateID) {
+	if len(d.window) >= d.windowSize {
+		delete(d.seen, d.window[0])
+		d.window = d.window[1:]
+	}
+
+	d.seen[updateID] = struct{}{}
+	d.window = append(d.window, updateID)
+}
+
+// Passes held updates to receiver, which are next in sequence, or which
+// deadline exceeded, together with all updates before them, and schedules
+// the next pass. Called under lock.
+func (d *UpdateDeduplicator) pass(now time.Time) {
+	expired := -1
+	for i, update := range d.held {
+		if !now.Before(d.deadlines[update.UpdateID]) {
+			expired = i
+		}
+	}
+
+	passed := 0
+	for i, update := range d.held {
+		next := d.lastPassed != 0 && update.UpdateID <= d.lastPassed+1
+		if i > expired && !next {
+			break
+		}
+
+		d.passUpdate(update)
+		passed++
+	}
+	d.held = d.held[passed:]
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	if len(d.held) == 0 {
+		return
+	}
+
+	earliest := d.deadlines[d.held[0].UpdateID]
+	for _, update := range d.held[1:] {
+		if deadline := d.deadlines[update.UpdateID]; deadline.Before(earliest) {
+			earliest = deadline
+		}
+	}
+
+	d.timer = time.AfterFunc(earliest.Sub(now), func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		d.pass(time.Now())
+	})
+}
+
+func (d *UpdateDeduplicator) passUpdate(update *Update) {
+	delete(d.deadlines, update.UpdateID)
+	if update.UpdateID > d.lastPassed {
+		d.lastPassed = update.UpdateID
+	}
+
+	d.receiver(update, nil)
+}
+
+// Returns number of held updates
+func (d *UpdateDeduplicator) Held() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.held)
+}
+
+// Passes all held updates to receiver in order, without waiting for their
+// delay, e.x. before shutdown
+func (d *UpdateDeduplicator) Flush() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, update := range d.held {
+		d.passUpdate(update)
+	}
+	d.held = nil
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
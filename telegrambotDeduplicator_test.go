@@ -0,0 +1,92 @@
+package telegrambot_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nickname76/telegrambot"
+)
+
+// Records identifiers of updates passed to receiver
+type updateRecorder struct {
+	mu        sync.Mutex
+	updateIDs []telegrambot.UpdateID
+}
+
+func (r *updateRecorder) Receive(update *telegrambot.Update, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.updateIDs = append(r.updateIDs, update.UpdateID)
+}
+
+func (r *updateRecorder) check(t *testing.T, want ...telegrambot.UpdateID) {
+	t.Helper()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !reflect.DeepEqual(r.updateIDs, want) {
+		t.Fatalf("received updates %v, want %v", r.updateIDs, want)
+	}
+}
+
+func receiveUpdateIDs(d *telegrambot.UpdateDeduplicator, updateIDs ...telegrambot.UpdateID) {
+	for _, updateID := range updateIDs {
+		d.Receive(&telegrambot.Update{UpdateID: updateID}, nil)
+	}
+}
+
+func TestUpdateDeduplicatorDropsRepeatedUpdates(t *testing.T) {
+	recorder := &updateRecorder{}
+	d := telegrambot.NewUpdateDeduplicator(&telegrambot.UpdateDeduplicatorParams{
+		WindowSize: 3,
+	}, recorder.Receive)
+
+	receiveUpdateIDs(d, 1, 2, 1, 3, 2, 4, 4)
+	recorder.check(t, 1, 2, 3, 4)
+
+	// 1 is out of window
+	receiveUpdateIDs(d, 1, 3)
+	recorder.check(t, 1, 2, 3, 4, 1)
+}
+
+func TestUpdateDeduplicatorRestoresOrder(t *testing.T) {
+	const reorderDelay = 50 * time.Millisecond
+
+	recorder := &updateRecorder{}
+	d := telegrambot.NewUpdateDeduplicator(&telegrambot.UpdateDeduplicatorParams{
+		ReorderDelay: reorderDelay,
+	}, recorder.Receive)
+
+	// Previous update is unknown, so updates are held for delay
+	receiveUpdateIDs(d, 10, 12, 11, 12)
+	recorder.check(t)
+	if held := d.Held(); held != 3 {
+		t.Fatalf("%v updates are held, want 3", held)
+	}
+
+	time.Sleep(2 * reorderDelay)
+	recorder.check(t, 10, 11, 12)
+
+	// The next update is passed at once, later ones wait for missing one
+	receiveUpdateIDs(d, 13, 15)
+	recorder.check(t, 10, 11, 12, 13)
+
+	receiveUpdateIDs(d, 14)
+	recorder.check(t, 10, 11, 12, 13, 14, 15)
+	if held := d.Held(); held != 0 {
+		t.Fatalf("%v updates are held, want 0", held)
+	}
+
+	// Missing update is not waited for longer than delay
+	receiveUpdateIDs(d, 17)
+	time.Sleep(2 * reorderDelay)
+	recorder.check(t, 10, 11, 12, 13, 14, 15, 17)
+
+	receiveUpdateIDs(d, 19)
+	d.Flush()
+	recorder.check(t, 10, 11, 12, 13, 14, 15, 17, 19)
+}
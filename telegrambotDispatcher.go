@@ -0,0 +1,397 @@
+package telegrambot
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Routes updates to handlers registered for their kind, if all filters of
+// handler pass. Handlers are checked in the order of registration, and only
+// the first matching one is called.
+//
+//	api, me, err := telegrambot.NewAPI(token)
+//	...
+//	d := telegrambot.NewDispatcher()
+//	d.HandleMessage(func(msg *telegrambot.Message) {
+//		...
+//	}, telegrambot.FilterCommand(me, "start"), telegrambot.FilterChatType(telegrambot.ChatTypePrivate))
+//	d.HandleCallbackQuery(func(cbQry *telegrambot.CallbackQuery) {
+//		...
+//	}, telegrambot.FilterCallbackDataPrefix("vote:"))
+//	d.HandleError(func(err error) {
+//		log.Printf("Error: %v", err)
+//	})
+//
+//	stop := telegrambot.StartReceivingUpdates(api, d.Receive)
+type Dispatcher struct {
+	mu           sync.RWMutex
+	handlers     []*dispatcherHandler
+	errorHandler func(err error)
+	fallback     func(update *Update)
+}
+
+type dispatcherHandler struct {
+	kind    UpdateType
+	filters []Filter
+	handle  func(update *Update)
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Receives update, has the same signature as receiver of
+// StartReceivingUpdates and NewWebhookHandler, so it can be passed to them
+func (d *Dispatcher) Receive(update *Update, err error) {
+	if err != nil {
+		d.mu.RLock()
+		errorHandler := d.errorHandler
+		d.mu.RUnlock()
+
+		if errorHandler != nil {
+			errorHandler(err)
+		}
+		return
+	}
+
+	d.Dispatch(update)
+}
+
+// Calls the first handler registered for update, returns false if there is
+// no such handler
+func (d *Dispatcher) Dispatch(update *Update) bool {
+	kind := update.Kind()
+
+	d.mu.RLock()
+	handlers := d.handlers
+	fallback := d.fallback
+	d.mu.RUnlock()
+
+	for _, h := range handlers {
+		if h.kind != "" && h.kind != kind {
+			continue
+		}
+
+		if !filtersPass(h.filters, update) {
+			continue
+		}
+
+		h.handle(update)
+
+		return true
+	}
+
+	if fallback != nil {
+		fallback(update)
+	}
+
+	return false
+}
+
+func filtersPass(filters []Filter, update *Update) bool {
+	for _, filter := range filters {
+		if !filter(update) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Registers handler of updates of kind. Empty kind means any update.
+func (d *Dispatcher) Handle(kind UpdateType, handler func(update *Update), filters ...Filter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Handlers are copied, so that Dispatch can iterate them without lock
+	handlers := make([]*dispatcherHandler, len(d.handlers), len(d.handlers)+1)
+	copy(handlers, d.handlers)
+
+	d.handlers = append(handlers, &dispatcherHandler{
+		kind:    kind,
+		filters: filters,
+		handle:  handler,
+	})
+}
+
+// Registers handler of errors of receiving updates
+func (d *Dispatcher) HandleError(handler func(err error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.errorHandler = handler
+}
+
+// Registers handler of updates, which no other handler matched
+func (d *Dispatcher) HandleFallback(handler func(update *Update)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.fallback = handler
+}
+
+func (d *Dispatcher) HandleMessage(handler func(msg *Message), filters ...Filter) {
+	d.Handle(UpdateTypeMessage, func(update *Update) {
+		handler(update.Message)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandleEditedMessage(handler func(msg *Message), filters ...Filter) {
+	d.Handle(UpdateTypeEditedMessage, func(update *Update) {
+		handler(update.EditedMessage)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandleChannelPost(handler func(msg *Message), filters ...Filter) {
+	d.Handle(UpdateTypeChannelPost, func(update *Update) {
+		handler(update.ChannelPost)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandleEditedChannelPost(handler func(msg *Message), filters ...Filter) {
+	d.Handle(UpdateTypeEditedChannelPost, func(update *Update) {
+		handler(update.EditedChannelPost)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandleInlineQuery(handler func(inlineQuery *InlineQuery), filters ...Filter) {
+	d.Handle(UpdateTypeInlineQuery, func(update *Update) {
+		handler(update.InlineQuery)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandleChosenInlineResult(handler func(chosenInlineResult *ChosenInlineResult), filters ...Filter) {
+	d.Handle(UpdateTypeChosenInlineResult, func(update *Update) {
+		handler(update.ChosenInlineResult)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandleCallbackQuery(handler func(cbQry *CallbackQuery), filters ...Filter) {
+	d.Handle(UpdateTypeCallbackQuery, func(update *Update) {
+		handler(update.CallbackQuery)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandleShippingQuery(handler func(shippingQuery *ShippingQuery), filters ...Filter) {
+	d.Handle(UpdateTypeShippingQuery, func(update *Update) {
+		handler(update.ShippingQuery)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandlePreCheckoutQuery(handler func(preCheckoutQuery *PreCheckoutQuery), filters ...Filter) {
+	d.Handle(UpdateTypePreCheckoutQuery, func(update *Update) {
+		handler(update.PreCheckoutQuery)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandlePoll(handler func(poll *Poll), filters ...Filter) {
+	d.Handle(UpdateTypePoll, func(update *Update) {
+		handler(update.Poll)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandlePollAnswer(handler func(pollAnswer *PollAnswer), filters ...Filter) {
+	d.Handle(UpdateTypePollAnswer, func(update *Update) {
+		handler(update.PollAnswer)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandleMyChatMember(handler func(chatMemberUpdated *ChatMemberUpdated), filters ...Filter) {
+	d.Handle(UpdateTypeMyChatMember, func(update *Update) {
+		handler(update.MyChatMember)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandleChatMember(handler func(chatMemberUpdated *ChatMemberUpdated), filters ...Filter) {
+	d.Handle(UpdateTypeChatMember, func(update *Update) {
+		handler(update.ChatMember)
+	}, filters...)
+}
+
+func (d *Dispatcher) HandleChatJoinRequest(handler func(chatJoinRequest *ChatJoinRequest), filters ...Filter) {
+	d.Handle(UpdateTypeChatJoinRequest, func(update *Update) {
+		handler(update.ChatJoinRequest)
+	}, filters...)
+}
+
+// Condition, which update must satisfy to be passed to handler of Dispatcher
+type Filter func(update *Update) bool
+
+// Passes updates, which pass all filters
+func FilterAnd(filters ...Filter) Filter {
+	return func(update *Update) bool {
+		return filtersPass(filters, update)
+	}
+}
+
+// Passes updates, which pass any of filters
+func FilterOr(filters ...Filter) Filter {
+	return func(update *Update) bool {
+		for _, filter := range filters {
+			if filter(update) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Passes updates, which do not pass filter
+func FilterNot(filter Filter) Filter {
+	return func(update *Update) bool {
+		return !filter(update)
+	}
+}
+
+// Passes updates from chats of chatTypes
+func FilterChatType(chatTypes ...ChatType) Filter {
+	return func(update *Update) bool {
+		chat := update.EffectiveChat()
+		if chat == nil {
+			return false
+		}
+
+		for _, chatType := range chatTypes {
+			if chat.Type == chatType {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Passes messages starting with one of commands, which are specified without
+// leading slash, e.x. "start". Any command passes, if commands are empty.
+// Commands addressed to other bots, e.x. "/start@other_bot" in groups, don't
+// pass. bot is the user of bot, returned by NewAPI or GetMe, if it is nil,
+// only commands without bot username pass.
+func FilterCommand(bot *User, commands ...string) Filter {
+	return func(update *Update) bool {
+		msg := update.EffectiveMessage()
+		if msg == nil {
+			return false
+		}
+
+		command, username, _ := parseMessageCommand(msg)
+		if command == "" {
+			return false
+		}
+
+		if username != "" && (bot == nil || !strings.EqualFold(username, string(bot.Username))) {
+			return false
+		}
+
+		if len(commands) == 0 {
+			return true
+		}
+
+		for _, c := range commands {
+			if strings.EqualFold(command, c) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Passes messages, which text or caption matches re, and inline queries,
+// which query matches re
+func FilterTextRegexp(re *regexp.Regexp) Filter {
+	return func(update *Update) bool {
+		if update.InlineQuery != nil {
+			return re.MatchString(update.InlineQuery.Query)
+		}
+
+		msg := update.EffectiveMessage()
+		if msg == nil {
+			return false
+		}
+
+		if msg.Text != "" {
+			return re.MatchString(msg.Text)
+		}
+
+		return msg.Caption != "" && re.MatchString(msg.Caption)
+	}
+}
+
+// Passes callback queries, which data starts with prefix
+func FilterCallbackDataPrefix(prefix string) Filter {
+	return func(update *Update) bool {
+		return update.CallbackQuery != nil && strings.HasPrefix(update.CallbackQuery.Data, prefix)
+	}
+}
+
+// Passes messages with media of mediaTypes
+func FilterMediaType(mediaTypes ...MessageMediaType) Filter {
+	return func(update *Update) bool {
+		msg := update.EffectiveMessage()
+		if msg == nil {
+			return false
+		}
+
+		for _, mediaType := range mediaTypes {
+			if messageHasMediaType(msg, mediaType) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+func messageHasMediaType(msg *Message, mediaType MessageMediaType) bool {
+	switch mediaType {
+	case MessageMediaTypeAnimation:
+		return msg.Animation != nil
+	case MessageMediaTypeAudio:
+		return msg.Audio != nil
+	case MessageMediaTypeDocument:
+		// Animations also have document field set, for backward compatibility
+		return msg.Document != nil && msg.Animation == nil
+	case MessageMediaTypePhoto:
+		return len(msg.Photo) != 0
+	case MessageMediaTypeSticker:
+		return msg.Sticker != nil
+	case MessageMediaTypeVideo:
+		return msg.Video != nil
+	case MessageMediaTypeVideoNote:
+		return msg.VideoNote != nil
+	case MessageMediaTypeVoice:
+		return msg.Voice != nil
+	case MessageMediaTypeContact:
+		return msg.Contact != nil
+	case MessageMediaTypeDice:
+		return msg.Dice != nil
+	case MessageMediaTypeGame:
+		return msg.Game != nil
+	case MessageMediaTypePoll:
+		return msg.Poll != nil
+	case MessageMediaTypeVenue:
+		return msg.Venue != nil
+	case MessageMediaTypeLocation:
+		// Venues also have location field set
+		return msg.Location != nil && msg.Venue == nil
+	}
+
+	return false
+}
+
+// Passes updates from users with userIDs. Messages sent on behalf of chats,
+// e.x. by anonymous administrators, do not pass, see Update.EffectiveUser.
+func FilterUsers(userIDs ...UserID) Filter {
+	allowed := make(map[UserID]bool, len(userIDs))
+	for _, userID := range userIDs {
+		allowed[userID] = true
+	}
+
+	return func(update *Update) bool {
+		user := update.EffectiveUser()
+
+		return user != nil && allowed[user.ID]
+	}
+}
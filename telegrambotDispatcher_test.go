@@ -0,0 +1,42 @@
+package telegrambot_test
+
+import (
+	"testing"
+
+	"github.com/nickname76/telegrambot"
+	"github.com/nickname76/telegrambot/telegrambottest"
+)
+
+func TestFilterCommandRejectsCommandsOfOtherBots(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	bot := server.Bot()
+	user := server.NewUser("Alice")
+	chat := server.PrivateChat(user)
+
+	filter := telegrambot.FilterCommand(bot, "start")
+
+	tests := []struct {
+		text string
+		pass bool
+	}{
+		{"/start", true},
+		{"/start@" + string(bot.Username), true},
+		{"/start@other_bot", false},
+		{"/help", false},
+		{"start", false},
+	}
+
+	for _, test := range tests {
+		update := server.AddMessage(user, chat, test.text)
+		if pass := filter(update); pass != test.pass {
+			t.Errorf("FilterCommand(%q) = %v, want %v", test.text, pass, test.pass)
+		}
+	}
+
+	update := server.AddMessage(user, chat, "/start@"+string(bot.Username))
+	if telegrambot.FilterCommand(nil)(update) {
+		t.Error("command addressed to bot passed filter without bot user")
+	}
+}
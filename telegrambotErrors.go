@@ -0,0 +1,408 @@
+package telegrambot
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Error returned by Telegram Bot API on unsuccessful request. Errors returned
+// by API methods wrap it, so use errors.As to get it
+//
+//	var apiErr *telegrambot.APIError
+//	if errors.As(err, &apiErr) && apiErr.ErrorCode == 403 {
+//		...
+//	}
+type APIError struct {
+	// Name of Bot API method, which returned error, e.x. "sendMessage"
+	Method string
+	// Error code, which is similar to HTTP status codes
+	ErrorCode int
+	// Human-readable description of the error
+	Description string
+	// Optional. Additional information about the error, may be nil
+	Parameters *ResponseParameters
+}
+
+// Returns error description, as it was received from Telegram Bot API
+func (e *APIError) Error() string {
+	return e.Description
+}
+
+// Well-known Bot API error, identified by error code and description. Bot API
+// descriptions are prefixed with a reason (e.x. "Bad Request: ") and sometimes
+// continued with details, so descriptions are matched by case-insensitive
+// substring.
+type apiErrorPattern struct {
+	errorCode    int
+	descriptions []string
+}
+
+func (aep *apiErrorPattern) match(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode != aep.errorCode {
+		return false
+	}
+
+	description := strings.ToLower(apiErr.Description)
+	for _, d := range aep.descriptions {
+		if strings.Contains(description, d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	apiErrorBotBlockedByUser = &apiErrorPattern{403, []string{
+		"bot was blocked by the user",
+	}}
+	apiErrorBotKicked = &apiErrorPattern{403, []string{
+		"bot was kicked from the group chat",
+		"bot was kicked from the supergroup chat",
+		"bot was kicked from the channel chat",
+		"bot is not a member of the group chat",
+		"bot is not a member of the supergroup chat",
+		"bot is not a member of the channel chat",
+	}}
+	apiErrorCantInitiateConversation = &apiErrorPattern{403, []string{
+		"bot can't initiate conversation with a user",
+		"bot can't send messages to bots",
+	}}
+	apiErrorUserDeactivated = &apiErrorPattern{403, []string{
+		"user is deactivated",
+	}}
+	apiErrorChatNotFound = &apiErrorPattern{400, []string{
+		"chat not found",
+	}}
+	apiErrorUserNotFound = &apiErrorPattern{400, []string{
+		"user not found",
+		"participant_id_invalid",
+	}}
+	apiErrorMessageNotModified = &apiErrorPattern{400, []string{
+		"message is not modified",
+	}}
+	apiErrorMessageToEditNotFound = &apiErrorPattern{400, []string{
+		"message to edit not found",
+	}}
+	apiErrorMessageToDeleteNotFound = &apiErrorPattern{400, []string{
+		"message to delete not found",
+	}}
+	apiErrorMessageCantBeEdited = &apiErrorPattern{400, []string{
+		"message can't be edited",
+	}}
+	apiErrorMessageCantBeDeleted = &apiErrorPattern{400, []string{
+		"message can't be deleted",
+	}}
+	apiErrorReplyMessageNotFound = &apiErrorPattern{400, []string{
+		"reply message not found",
+		"replied message not found",
+	}}
+	apiErrorQueryTooOld = &apiErrorPattern{400, []string{
+		"query is too old",
+	}}
+	apiErrorNotEnoughRights = &apiErrorPattern{400, []string{
+		"not enough rights",
+		"have no rights",
+		"chat_admin_required",
+	}}
+	apiErrorMessageTextEmpty = &apiErrorPattern{400, []string{
+		"message text is empty",
+	}}
+	apiErrorMessageTooLong = &apiErrorPattern{400, []string{
+		"message is too long",
+	}}
+	apiErrorWrongFileID = &apiErrorPattern{400, []string{
+		"wrong file identifier",
+		"wrong remote file identifier",
+	}}
+	apiErrorWebhookActive = &apiErrorPattern{409, []string{
+		"webhook is active",
+	}}
+)
+
+// Returns true, if err is APIError with specified error code
+func IsAPIErrorCode(err error, errorCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode == errorCode
+}
+
+// Returns true, if bot was blocked by the user, so it can't send messages to
+// them. Error code 403, "Forbidden: bot was blocked by the user"
+func IsBotBlockedByUser(err error) bool {
+	return apiErrorBotBlockedByUser.match(err)
+}
+
+// Returns true, if bot was kicked from the chat or is not its member. Error
+// code 403, e.x. "Forbidden: bot was kicked from the supergroup chat"
+func IsBotKicked(err error) bool {
+	return apiErrorBotKicked.match(err)
+}
+
+// Returns true, if user has never started a conversation with the bot, or the
+// target is another bot. Error code 403, e.x. "Forbidden: bot can't initiate
+// conversation with a user"
+func IsCantInitiateConversation(err error) bool {
+	return apiErrorCantInitiateConversation.match(err)
+}
+
+// Returns true, if target user account is deleted. Error code 403,
+// "Forbidden: user is deactivated"
+func IsUserDeactivated(err error) bool {
+	return apiErrorUserDeactivated.match(err)
+}
+
+// Returns true, if chat does not exist or is not accessible by the bot. Error
+// code 400, "Bad Request: chat not found"
+func IsChatNotFound(err error) bool {
+	return apiErrorChatNotFound.match(err)
+}
+
+// Returns true, if user does not exist or is not a member of the chat. Error
+// code 400, e.x. "Bad Request: user not found"
+func IsUserNotFound(err error) bool {
+	return apiErrorUserNotFound.match(err)
+}
+
+// Returns true, if edited message content and reply markup are exactly the
+// same as the current ones. Error code 400, "Bad Request: message is not
+// modified: ..."
+func IsMessageNotModified(err error) bool {
+	return apiErrorMessageNotModified.match(err)
+}
+
+// Returns true, if message to edit does not exist or was deleted. Error code
+// 400, "Bad Request: message to edit not found"
+func IsMessageToEditNotFound(err error) bool {
+	return apiErrorMessageToEditNotFound.match(err)
+}
+
+// Returns true, if message to delete does not exist or was already deleted.
+// Error code 400, "Bad Request: message to delete not found"
+func IsMessageToDeleteNotFound(err error) bool {
+	return apiErrorMessageToDeleteNotFound.match(err)
+}
+
+// Returns true, if message can't be edited, e.x. it is too old or was sent by
+// another user. Error code 400, "Bad Request: message can't be edited"
+func IsMessageCantBeEdited(err error) bool {
+	return apiErrorMessageCantBeEdited.match(err)
+}
+
+// Returns true, if message can't be deleted, e.x. it is older than 48 hours.
+// Error code 400, "Bad Request: message can't be deleted"
+func IsMessageCantBeDeleted(err error) bool {
+	return apiErrorMessageCantBeDeleted.match(err)
+}
+
+// Returns true, if message specified in ReplyToMessageID does not exist. Error
+// code 400, "Bad Request: reply message not found"
+func IsReplyMessageNotFound(err error) bool {
+	return apiErrorReplyMessageNotFound.match(err)
+}
+
+// Returns true, if callback or inline query was answered too late or its ID is
+// invalid. Error code 400, "Bad Request: query is too old and response timeout
+// expired or query ID is invalid"
+func IsQueryTooOld(err error) bool {
+	return apiErrorQueryTooOld.match(err)
+}
+
+// Returns true, if bot lacks administrator rights or permissions for the
+// action in the chat. Error code 400, e.x. "Bad Request: not enough rights to
+// send text messages to the chat"
+func IsNotEnoughRights(err error) bool {
+	return apiErrorNotEnoughRights.match(err)
+}
+
+// Returns true, if message text is empty. Error code 400, "Bad Request:
+// message text is empty"
+func IsMessageTextEmpty(err error) bool {
+	return apiErrorMessageTextEmpty.match(err)
+}
+
+// Returns true, if message text exceeds 4096 characters. Error code 400, "Bad
+// Request: message is too long"
+func IsMessageTooLong(err error) bool {
+	return apiErrorMessageTooLong.match(err)
+}
+
+// Returns true, if file identifier is invalid or belongs to another bot. Error
+// code 400, e.x. "Bad Request: wrong file identifier/HTTP URL specified"
+func IsWrongFileID(err error) bool {
+	return apiErrorWrongFileID.match(err)
+}
+
+// Returns true, if flood control is exceeded. Error code 429, "Too Many
+// Requests: retry after N"
+func IsTooManyRequests(err error) bool {
+	return IsAPIErrorCode(err, 429)
+}
+
+// Returns true, if bot token is invalid or was revoked. Error code 401,
+// "Unauthorized"
+func IsUnauthorized(err error) bool {
+	return IsAPIErrorCode(err, 401)
+}
+
+// Returns true, if request conflicts with another one, e.x. getUpdates is
+// called while webhook is set, or another bot instance is polling updates.
+// Error code 409, e.x. "Conflict: can't use getUpdates method while webhook is
+// active; use deleteWebhook to delete the webhook first"
+func IsConflict(err error) bool {
+	return IsAPIErrorCode(err, 409)
+}
+
+// Returns true, if getUpdates is called while webhook is set. Error code 409,
+// "Conflict: can't use getUpdates method while webhook is active; use
+// deleteWebhook to delete the webhook first"
+func IsWebhookActive(err error) bool {
+	return apiErrorWebhookActive.match(err)
+}
+
+// Error returned, when group chat was upgraded to a supergroup and request
+// should be repeated with the new chat identifier. Returned unless
+// API.ResendOnChatMigration is enabled. Errors returned by API methods wrap it,
+// so use errors.As to get it.
+//
+// https://core.telegram.org/bots/api#responseparameters
+type ChatMigratedError struct {
+	// Identifier of the group chat, which was passed in request. Zero, if
+	// request had no numeric chat_id
+	OldChatID ChatID
+	// Identifier of the supergroup chat, which group was migrated to
+	NewChatID ChatID
+	// Original error returned by Telegram Bot API
+	APIError *APIError
+}
+
+func (e *ChatMigratedError) Error() string {
+	return fmt.Sprintf("chat %v migrated to supergroup %v: %v", e.OldChatID, e.NewChatID, e.APIError.Description)
+}
+
+func (e *ChatMigratedError) Unwrap() error {
+	return e.APIError
+}
+
+// Returns new chat identifier, if err is caused by group chat migration to a
+// supergroup
+func IsChatMigrated(err error) (newChatID ChatID, ok bool) {
+	var chatMigratedErr *ChatMigratedError
+	if !errors.As(err, &chatMigratedErr) {
+		return 0, false
+	}
+
+	return chatMigratedErr.NewChatID, true
+}
+
+// Error returned, when response can't be decoded as Bot API response, e.x.
+// when a proxy or Bot API server responds with "502 Bad Gateway" page.
+// Errors returned by API methods wrap it, so use errors.As to get it.
+type ResponseDecodeError struct {
+	// Beginning of the response body, up to 512 bytes
+	Body []byte
+	// True, if response is a Bot API response, but its result or parameters
+	// can't be decoded, e.x. because of mismatching types. Such request was
+	// performed by Telegram, so it is not repeated.
+	APIResponse bool
+	// Decoding error
+	Err error
+}
+
+func newResponseDecodeError(respBody []byte, err error) *ResponseDecodeError {
+	apiResponse := jsoniter.Get(respBody, "ok").ValueType() == jsoniter.BoolValue
+
+	if len(respBody) > 512 {
+		respBody = respBody[:512]
+	}
+
+	return &ResponseDecodeError{
+		Body:        append([]byte(nil), respBody...),
+		APIResponse: apiResponse,
+		Err:         err,
+	}
+}
+
+func (e *ResponseDecodeError) Error() string {
+	return fmt.Sprintf("can't decode response: %v", e.Err)
+}
+
+func (e *ResponseDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Error of http request with bot token replaced in its message. Errors it
+// wraps are also redacted, so token can't be reached with errors.Unwrap or
+// errors.As.
+type redactedError struct {
+	message string
+	err     error
+}
+
+// Returns err, which chain contains no bot token. *url.Error is copied with
+// redacted URL, other errors containing token are replaced with
+// redactedError, wrapping redacted cause.
+func redactError(err error, token string) error {
+	if err == nil || token == "" || !errorChainContains(err, token) {
+		return err
+	}
+
+	if urlErr, ok := err.(*url.Error); ok {
+		return &url.Error{
+			Op:  urlErr.Op,
+			URL: redactToken(urlErr.URL, token),
+			Err: redactError(urlErr.Err, token),
+		}
+	}
+
+	return &redactedError{
+		message: redactToken(err.Error(), token),
+		err:     redactError(errors.Unwrap(err), token),
+	}
+}
+
+// Reports whether message of err or any error it wraps contains token
+func errorChainContains(err error, token string) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if strings.Contains(err.Error(), token) {
+			return true
+		}
+
+		if urlErr, ok := err.(*url.Error); ok && strings.Contains(urlErr.URL, token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Replaces bot token in s with its masked version
+func redactToken(s string, token string) string {
+	if token == "" {
+		return s
+	}
+
+	return strings.ReplaceAll(s, token, maskToken(token))
+}
+
+// Masks secret part of bot token, e.x. "123456:***"
+func maskToken(token string) string {
+	botID, _, ok := strings.Cut(token, ":")
+	if !ok {
+		return "***"
+	}
+
+	return botID + ":***"
+}
@@ -0,0 +1,187 @@
+package telegrambot_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/nickname76/telegrambot"
+)
+
+const testToken = "123456:SECRET-token"
+
+var errTransport = errors.New("connection refused")
+
+// Returns error, which http.Client returns for failed request
+func transportError(reqURL string) error {
+	return &url.Error{
+		Op:  "Post",
+		URL: reqURL,
+		Err: errTransport,
+	}
+}
+
+func checkErrorRedacted(t *testing.T, err error) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if strings.Contains(e.Error(), "SECRET") {
+			t.Fatalf("error in chain contains token: %v", e)
+		}
+	}
+
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Fatalf("url.Error is not in chain of %v", err)
+	}
+	if strings.Contains(urlErr.URL, "SECRET") {
+		t.Fatalf("url.Error contains token: %v", urlErr.URL)
+	}
+
+	if !errors.Is(err, errTransport) {
+		t.Fatalf("cause is not in chain of %v", err)
+	}
+}
+
+func TestTransportErrorRedacted(t *testing.T) {
+	api := &telegrambot.API{
+		Token:       testToken,
+		EndpointURL: telegrambot.DefaultAPIEndpointURL,
+		HttpDoRequestContext: func(ctx context.Context, method string, url string, headers map[string]string, body []byte) ([]byte, error) {
+			return nil, transportError(url)
+		},
+	}
+
+	_, err := api.GetMe()
+	checkErrorRedacted(t, err)
+}
+
+func TestStreamTransportErrorRedacted(t *testing.T) {
+	api := &telegrambot.API{
+		Token:       testToken,
+		EndpointURL: telegrambot.DefaultAPIEndpointURL,
+		HttpDoStreamRequest: func(ctx context.Context, method string, url string, headers map[string]string, body io.Reader, bodySize int64) ([]byte, error) {
+			io.Copy(io.Discard, body)
+			return nil, transportError(url)
+		},
+	}
+
+	_, err := api.SendDocument(&telegrambot.SendDocumentParams{
+		ChatID: telegrambot.ChatID(1),
+		Document: &telegrambot.FileReader{
+			Name:   "file.txt",
+			Reader: strings.NewReader("content"),
+		},
+	})
+	checkErrorRedacted(t, err)
+}
+
+var errorPredicates = []struct {
+	name      string
+	predicate func(err error) bool
+}{
+	{"IsBotBlockedByUser", telegrambot.IsBotBlockedByUser},
+	{"IsBotKicked", telegrambot.IsBotKicked},
+	{"IsCantInitiateConversation", telegrambot.IsCantInitiateConversation},
+	{"IsUserDeactivated", telegrambot.IsUserDeactivated},
+	{"IsChatNotFound", telegrambot.IsChatNotFound},
+	{"IsUserNotFound", telegrambot.IsUserNotFound},
+	{"IsMessageNotModified", telegrambot.IsMessageNotModified},
+	{"IsMessageToEditNotFound", telegrambot.IsMessageToEditNotFound},
+	{"IsMessageToDeleteNotFound", telegrambot.IsMessageToDeleteNotFound},
+	{"IsMessageCantBeEdited", telegrambot.IsMessageCantBeEdited},
+	{"IsMessageCantBeDeleted", telegrambot.IsMessageCantBeDeleted},
+	{"IsReplyMessageNotFound", telegrambot.IsReplyMessageNotFound},
+	{"IsQueryTooOld", telegrambot.IsQueryTooOld},
+	{"IsNotEnoughRights", telegrambot.IsNotEnoughRights},
+	{"IsMessageTextEmpty", telegrambot.IsMessageTextEmpty},
+	{"IsMessageTooLong", telegrambot.IsMessageTooLong},
+	{"IsWrongFileID", telegrambot.IsWrongFileID},
+	{"IsTooManyRequests", telegrambot.IsTooManyRequests},
+	{"IsUnauthorized", telegrambot.IsUnauthorized},
+	{"IsConflict", telegrambot.IsConflict},
+	{"IsWebhookActive", telegrambot.IsWebhookActive},
+}
+
+// Descriptions of errors, as Bot API returns them
+var errorPredicateTests = []struct {
+	errorCode   int
+	description string
+	// Predicates, which match error
+	match []string
+}{
+	{403, "Forbidden: bot was blocked by the user", []string{"IsBotBlockedByUser"}},
+	{403, "Forbidden: bot was kicked from the supergroup chat", []string{"IsBotKicked"}},
+	{403, "Forbidden: bot is not a member of the channel chat", []string{"IsBotKicked"}},
+	{403, "Forbidden: bot can't initiate conversation with a user", []string{"IsCantInitiateConversation"}},
+	{403, "Forbidden: bot can't send messages to bots", []string{"IsCantInitiateConversation"}},
+	{403, "Forbidden: user is deactivated", []string{"IsUserDeactivated"}},
+	{400, "Bad Request: chat not found", []string{"IsChatNotFound"}},
+	{400, "Bad Request: user not found", []string{"IsUserNotFound"}},
+	{400, "Bad Request: PARTICIPANT_ID_INVALID", []string{"IsUserNotFound"}},
+	{400, "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message", []string{"IsMessageNotModified"}},
+	{400, "Bad Request: message to edit not found", []string{"IsMessageToEditNotFound"}},
+	{400, "Bad Request: message to delete not found", []string{"IsMessageToDeleteNotFound"}},
+	{400, "Bad Request: message can't be edited", []string{"IsMessageCantBeEdited"}},
+	{400, "Bad Request: message can't be deleted for everyone", []string{"IsMessageCantBeDeleted"}},
+	{400, "Bad Request: reply message not found", []string{"IsReplyMessageNotFound"}},
+	{400, "Bad Request: replied message not found", []string{"IsReplyMessageNotFound"}},
+	{400, "Bad Request: query is too old and response timeout expired or query ID is invalid", []string{"IsQueryTooOld"}},
+	{400, "Bad Request: not enough rights to send text messages to the chat", []string{"IsNotEnoughRights"}},
+	{400, "Bad Request: have no rights to send a message", []string{"IsNotEnoughRights"}},
+	{400, "Bad Request: CHAT_ADMIN_REQUIRED", []string{"IsNotEnoughRights"}},
+	{400, "Bad Request: message text is empty", []string{"IsMessageTextEmpty"}},
+	{400, "Bad Request: message is too long", []string{"IsMessageTooLong"}},
+	{400, "Bad Request: wrong file identifier/HTTP URL specified", []string{"IsWrongFileID"}},
+	{400, "Bad Request: wrong remote file identifier specified: Wrong padding in the string", []string{"IsWrongFileID"}},
+	{429, "Too Many Requests: retry after 5", []string{"IsTooManyRequests"}},
+	{401, "Unauthorized", []string{"IsUnauthorized"}},
+	{409, "Conflict: terminated by other getUpdates request; make sure that only one bot instance is running", []string{"IsConflict"}},
+	{409, "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first", []string{"IsConflict", "IsWebhookActive"}},
+}
+
+func TestErrorPredicates(t *testing.T) {
+	for _, test := range errorPredicateTests {
+		match := map[string]bool{}
+		for _, name := range test.match {
+			match[name] = true
+		}
+
+		// Errors returned by API methods wrap APIError
+		err := fmt.Errorf("SendMessage: %w", &telegrambot.APIError{
+			Method:      "sendMessage",
+			ErrorCode:   test.errorCode,
+			Description: test.description,
+		})
+
+		// The same description with other error code is another error
+		wrongCodeErr := fmt.Errorf("SendMessage: %w", &telegrambot.APIError{
+			Method:      "sendMessage",
+			ErrorCode:   500,
+			Description: test.description,
+		})
+
+		for _, p := range errorPredicates {
+			if got := p.predicate(err); got != match[p.name] {
+				t.Errorf("%v(%v %q) = %v, want %v", p.name, test.errorCode, test.description, got, match[p.name])
+			}
+			if p.predicate(wrongCodeErr) {
+				t.Errorf("%v(500 %q) = true, want false", p.name, test.description)
+			}
+		}
+	}
+
+	for _, p := range errorPredicates {
+		if p.predicate(errTransport) {
+			t.Errorf("%v(%v) = true, want false", p.name, errTransport)
+		}
+	}
+}
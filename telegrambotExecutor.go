@@ -0,0 +1,164 @@
+package telegrambot
+
+import (
+	"sync"
+)
+
+type UpdateExecutorParams struct {
+	// Optional. Number of goroutines handling updates. Defaults to 16.
+	Workers int
+	// Optional. Number of updates, which can wait for each worker. Defaults to
+	// 64.
+	QueueSize int
+	// Optional. Returns key of update, updates with the same key are handled
+	// sequentially in the order they were received. Defaults to identifier of
+	// chat of update, or of user, if update has no chat.
+	Key func(update *Update) int64
+}
+
+// Handles updates concurrently by a pool of workers, keeping order of
+// updates from the same chat. Updates are distributed between workers by
+// their key, each worker has a bounded queue.
+//
+// Receive blocks, when queue of worker is full, so updates receiving is
+// paused until workers catch up.
+//
+//	executor := telegrambot.NewUpdateExecutor(nil, dispatcher.Receive)
+//	stop := telegrambot.StartReceivingUpdates(api, executor.Receive)
+//	...
+//	stop()
+//	executor.Close()
+type UpdateExecutor struct {
+	receiver func(update *Update, err error)
+	key      func(update *Update) int64
+
+	mu     sync.RWMutex
+	closed bool
+	queues []chan *Update
+	// Closed by Close, to unblock Receive waiting for full queue
+	closing chan struct{}
+	// Calls of Receive sending update to queue
+	sending sync.WaitGroup
+	wg      sync.WaitGroup
+}
+
+// Creates executor, which passes updates to receiver. receiver is called
+// concurrently for updates with different keys. params can be nil.
+func NewUpdateExecutor(params *UpdateExecutorParams, receiver func(update *Update, err error)) *UpdateExecutor {
+	if params == nil {
+		params = &UpdateExecutorParams{}
+	}
+
+	workers := params.Workers
+	if workers <= 0 {
+		workers = 16
+	}
+
+	queueSize := params.QueueSize
+	if queueSize <= 0 {
+		queueSize = 64
+	}
+
+	key := params.Key
+	if key == nil {
+		key = defaultUpdateKey
+	}
+
+	e := &UpdateExecutor{
+		receiver: receiver,
+		key:      key,
+		queues:   make([]chan *Update, workers),
+		closing:  make(chan struct{}),
+	}
+
+	e.wg.Add(workers)
+	for i := range e.queues {
+		queue := make(chan *Update, queueSize)
+		e.queues[i] = queue
+
+		go func() {
+			defer e.wg.Done()
+
+			for update := range queue {
+				e.receiver(update, nil)
+			}
+		}()
+	}
+
+	return e
+}
+
+func defaultUpdateKey(update *Update) int64 {
+	if chat := update.EffectiveChat(); chat != nil {
+		return int64(chat.ID)
+	}
+
+	if user := update.EffectiveUser(); user != nil {
+		return int64(user.ID)
+	}
+
+	return int64(update.UpdateID)
+}
+
+// Queues update for handling, blocks while queue of its worker is full.
+// Errors are passed to receiver immediately. Has the same signature as
+// receiver of StartReceivingUpdates and NewWebhookHandler. Updates received
+// after Close, or waiting for full queue, when Close is called, are dropped.
+func (e *UpdateExecutor) Receive(update *Update, err error) {
+	if err != nil {
+		e.receiver(nil, err)
+		return
+	}
+
+	// Converted to unsigned, as negation of the minimal int64 is negative
+	queue := e.queues[uint64(e.key(update))%uint64(len(e.queues))]
+
+	e.mu.RLock()
+	if e.closed {
+		e.mu.RUnlock()
+		return
+	}
+	e.sending.Add(1)
+	e.mu.RUnlock()
+
+	defer e.sending.Done()
+
+	// Lock is not held while waiting, so that handlers can call Queued
+	select {
+	case queue <- update:
+	case <-e.closing:
+	}
+}
+
+// Returns number of updates waiting in queues
+func (e *UpdateExecutor) Queued() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	queued := 0
+	for _, queue := range e.queues {
+		queued += len(queue)
+	}
+
+	return queued
+}
+
+// Stops accepting updates and waits for queued updates to be handled
+func (e *UpdateExecutor) Close() {
+	e.mu.Lock()
+	closed := e.closed
+	e.closed = true
+	e.mu.Unlock()
+
+	if !closed {
+		close(e.closing)
+
+		// Queues are closed, when nothing sends to them
+		e.sending.Wait()
+		for _, queue := range e.queues {
+			close(queue)
+		}
+	}
+
+	e.wg.Wait()
+}
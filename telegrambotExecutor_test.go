@@ -0,0 +1,129 @@
+package telegrambot_test
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nickname76/telegrambot"
+)
+
+func TestUpdateExecutorKeepsOrderOfChat(t *testing.T) {
+	const chats = 8
+
+	var (
+		mu        sync.Mutex
+		last      = map[telegrambot.ChatID]telegrambot.UpdateID{}
+		active    = 0
+		maxActive = 0
+	)
+
+	executor := telegrambot.NewUpdateExecutor(&telegrambot.UpdateExecutorParams{
+		Workers:   4,
+		QueueSize: 2,
+	}, func(update *telegrambot.Update, err error) {
+		chatID := update.Message.Chat.ID
+
+		mu.Lock()
+		if update.UpdateID <= last[chatID] {
+			t.Errorf("update %v of chat %v is handled after update %v", update.UpdateID, chatID, last[chatID])
+		}
+		last[chatID] = update.UpdateID
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+	})
+
+	for i := 1; i <= 200; i++ {
+		executor.Receive(&telegrambot.Update{
+			UpdateID: telegrambot.UpdateID(i),
+			Message: &telegrambot.Message{
+				Chat: &telegrambot.Chat{ID: telegrambot.ChatID(-(i % chats))},
+			},
+		}, nil)
+	}
+
+	executor.Close()
+
+	if queued := executor.Queued(); queued != 0 {
+		t.Errorf("%v updates are queued after Close", queued)
+	}
+	if len(last) != chats {
+		t.Errorf("updates of %v chats are handled, want %v", len(last), chats)
+	}
+	if maxActive < 2 {
+		t.Error("updates of different chats are not handled concurrently")
+	}
+}
+
+func TestUpdateExecutorCloseWithFullQueue(t *testing.T) {
+	release := make(chan struct{})
+
+	var executor *telegrambot.UpdateExecutor
+	executor = telegrambot.NewUpdateExecutor(&telegrambot.UpdateExecutorParams{
+		Workers:   1,
+		QueueSize: 1,
+	}, func(update *telegrambot.Update, err error) {
+		<-release
+		executor.Queued()
+	})
+
+	// The first update is handled, the second one is queued, and the third
+	// one waits for full queue
+	received := make(chan struct{})
+	go func() {
+		for i := 1; i <= 3; i++ {
+			executor.Receive(&telegrambot.Update{UpdateID: telegrambot.UpdateID(i)}, nil)
+		}
+		close(received)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		executor.Close()
+		close(closed)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close is blocked")
+	}
+	<-received
+}
+
+func TestUpdateExecutorMinimalKey(t *testing.T) {
+	handled := make(chan *telegrambot.Update, 1)
+
+	executor := telegrambot.NewUpdateExecutor(&telegrambot.UpdateExecutorParams{
+		Workers: 3,
+		Key: func(update *telegrambot.Update) int64 {
+			return math.MinInt64
+		},
+	}, func(update *telegrambot.Update, err error) {
+		handled <- update
+	})
+	defer executor.Close()
+
+	executor.Receive(&telegrambot.Update{UpdateID: 1}, nil)
+
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("update is not handled")
+	}
+}
@@ -0,0 +1,144 @@
+package telegrambot_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickname76/telegrambot"
+)
+
+// Reader, which returns chunks of data with delay before each one
+type slowReader struct {
+	chunks int
+	delay  time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.chunks == 0 {
+		return 0, io.EOF
+	}
+	r.chunks--
+
+	time.Sleep(r.delay)
+
+	n := copy(p, strings.Repeat("x", 100))
+
+	return n, nil
+}
+
+func newUploadServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.Copy(io.Discard, r.Body)
+		if err != nil {
+			return
+		}
+
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+}
+
+func TestHttpTransportStreamedUploadLongerThanTimeout(t *testing.T) {
+	server := newUploadServer(t)
+	defer server.Close()
+
+	transport, err := telegrambot.NewHttpTransport(&telegrambot.HttpTransportParams{
+		RequestTimeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload takes 500 ms, but body is sent every 50 ms
+	_, err = transport.DoStreamRequest(context.Background(), "POST", server.URL+"/bot/sendDocument", nil, &slowReader{
+		chunks: 10,
+		delay:  50 * time.Millisecond,
+	}, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHttpTransportStreamedUploadStalled(t *testing.T) {
+	server := newUploadServer(t)
+	defer server.Close()
+
+	transport, err := telegrambot.NewHttpTransport(&telegrambot.HttpTransportParams{
+		RequestTimeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = transport.DoStreamRequest(context.Background(), "POST", server.URL+"/bot/sendDocument", nil, &slowReader{
+		chunks: 2,
+		delay:  300 * time.Millisecond,
+	}, -1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DoStreamRequest returned %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestHttpTransportGetUpdatesTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	transport, err := telegrambot.NewHttpTransport(&telegrambot.HttpTransportParams{
+		RequestTimeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(`{"timeout":1}`)
+
+	_, err = transport.DoRequest(context.Background(), "POST", server.URL+"/bot/getUpdates", nil, body)
+	if err != nil {
+		t.Fatalf("long polling request is not extended by its timeout: %v", err)
+	}
+
+	_, err = transport.DoRequest(context.Background(), "POST", server.URL+"/bot/getMe", nil, body)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DoRequest returned %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestHttpTransportProxy(t *testing.T) {
+	proxiedHost := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost <- r.URL.Host
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer proxy.Close()
+
+	transport, err := telegrambot.NewHttpTransport(&telegrambot.HttpTransportParams{
+		ProxyURL: proxy.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = transport.DoRequest(context.Background(), "POST", "http://api.telegram.invalid/bot/getMe", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if host := <-proxiedHost; host != "api.telegram.invalid" {
+		t.Errorf("proxy received request to %q", host)
+	}
+
+	_, err = telegrambot.NewHttpTransport(&telegrambot.HttpTransportParams{
+		ProxyURL: "ftp://proxy.invalid",
+	})
+	if err == nil {
+		t.Error("proxy with unsupported scheme is accepted")
+	}
+}
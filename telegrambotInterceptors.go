@@ -0,0 +1,35 @@
+package telegrambot
+
+import (
+	"context"
+	"time"
+)
+
+// API call passed to interceptors
+type APICall struct {
+	// Name of Bot API method, e.x. "sendMessage"
+	Method string
+	// Request params, e.x. *SendMessageParams. Nil for methods without params
+	Params any
+	// Files of request, some of them may be nil
+	InputFiles []InputFile
+	// Destination of decoded result, e.x. *Message. It is filled after
+	// successful invoke. Nil for methods returning only True.
+	Result any
+	// Number of repeats of the call by RetryPolicy. Set after invoke.
+	Retries int
+	// Time spent on the call, including repeats. Set after invoke.
+	Latency time.Duration
+}
+
+// Intercepts API call. Interceptor performs call by calling invoke, and may
+// change ctx passed to it, e.x. to add tracing span. Interceptor may also not
+// call invoke, e.x. in dry-run mode, then it must fill call.Result itself, if
+// caller relies on it.
+//
+//	api.Interceptors = append(api.Interceptors, func(ctx context.Context, call *telegrambot.APICall, invoke func(ctx context.Context) error) error {
+//		err := invoke(ctx)
+//		log.Printf("%v: retries %v, latency %v, error %v", call.Method, call.Retries, call.Latency, err)
+//		return err
+//	})
+type Interceptor func(ctx context.Context, call *APICall, invoke func(ctx context.Context) error) error
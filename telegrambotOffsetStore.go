@@ -0,0 +1,107 @@
+package telegrambot
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Storage of offset of the next update to handle, which is UpdateID of the
+// last handled update plus one. See PollerParams.OffsetStore.
+type OffsetStore interface {
+	// Returns saved offset, or zero, if nothing was saved
+	Load() (UpdateID, error)
+	// Saves offset. Called after updates are handled, offset only grows.
+	Save(offset UpdateID) error
+}
+
+// Keeps offset in memory, e.x. for tests or to share offset between pollers
+// in the same process
+type MemoryOffsetStore struct {
+	mu     sync.Mutex
+	offset UpdateID
+}
+
+// Creates offset store, which keeps offset in memory
+func NewMemoryOffsetStore() *MemoryOffsetStore {
+	return &MemoryOffsetStore{}
+}
+
+func (s *MemoryOffsetStore) Load() (UpdateID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.offset, nil
+}
+
+func (s *MemoryOffsetStore) Save(offset UpdateID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offset = offset
+
+	return nil
+}
+
+// Keeps offset in a file as decimal number. File is replaced atomically on
+// each save, so it is not corrupted, if process crashes.
+type FileOffsetStore struct {
+	path string
+}
+
+// Creates offset store, which keeps offset in file at path. File is created
+// on the first save.
+func NewFileOffsetStore(path string) *FileOffsetStore {
+	return &FileOffsetStore{
+		path: path,
+	}
+}
+
+func (s *FileOffsetStore) Load() (UpdateID, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("FileOffsetStore.Load: %w", err)
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("FileOffsetStore.Load: %w", err)
+	}
+
+	return UpdateID(offset), nil
+}
+
+func (s *FileOffsetStore) Save(offset UpdateID) error {
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("FileOffsetStore.Save: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(strconv.FormatInt(int64(offset), 10) + "\n")
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("FileOffsetStore.Save: %w", err)
+	}
+
+	err = os.Rename(f.Name(), s.path)
+	if err != nil {
+		return fmt.Errorf("FileOffsetStore.Save: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,461 @@
+package telegrambot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type PollerParams struct {
+	// Optional. Parameters of GetUpdates requests. Offset is used as initial
+	// offset. Defaults to all types of updates and timeout of 2
+	// seconds, same as StartReceivingUpdates.
+	GetUpdatesParams *GetUpdatesParams
+	// Optional. Backoff before the first repeat of failed GetUpdates request.
+	// Defaults to 1 second.
+	InitialBackoff time.Duration
+	// Optional. Maximum backoff between repeats of failed GetUpdates
+	// requests, backoff doubles after each failure. Defaults to 30 seconds.
+	MaxBackoff time.Duration
+	// Optional. Called, when GetUpdates fails with 409 Conflict. Polling is
+	// continued, if it returns true, otherwise poller stops. Use
+	// DeleteWebhookOnConflict to delete webhook, which causes conflict.
+	OnConflict func(api *API, err error) bool
+	// Optional. Storage of offset of the next update to handle. Offset is
+	// loaded on start and saved after updates are handled, so that restarted
+	// poller resumes after the last handled update.
+	OffsetStore OffsetStore
+	// Optional. If true, update is handled, when Ack is called for it,
+	// instead of when receiver returns. Use it, when updates are handled
+	// asynchronously, e.x. with UpdateExecutor.
+	ManualAck bool
+}
+
+// Receives updates with long polling. Unlike StartReceivingUpdatesWithParams,
+// Shutdown waits for updates being handled and confirms handled updates to
+// Telegram, so they are not received again after restart.
+//
+//	poller := telegrambot.NewPoller(api, nil)
+//	poller.Start(receiver)
+//	...
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	err := poller.Shutdown(ctx)
+//
+// Failed requests are repeated with exponential backoff. Poller stops, if bot
+// token is invalid (401 Unauthorized), or if updates can't be received
+// because of webhook or another poller (409 Conflict), see Done and Err.
+//
+// Update is confirmed to Telegram and saved to OffsetStore only after it and
+// all previous updates are handled, so updates are handled at least once,
+// even if process crashes.
+type Poller struct {
+	api         *API
+	params      GetUpdatesParams
+	backoff     *RetryPolicy
+	onConflict  func(api *API, err error) bool
+	offsetStore OffsetStore
+	manualAck   bool
+	receiver    func(update *Update, err error)
+
+	mu      sync.Mutex
+	started bool
+	// UpdateID of the last update passed to receiver plus one
+	next     UpdateID
+	inFlight map[UpdateID]*Update
+	// Closed and replaced, when update is handled
+	handled chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+	err     error
+
+	saveMu sync.Mutex
+	saved  UpdateID
+}
+
+// Creates poller. params can be nil.
+func NewPoller(api *API, params *PollerParams) *Poller {
+	if params == nil {
+		params = &PollerParams{}
+	}
+
+	getUpdatesParams := GetUpdatesParams{
+		Timeout:        2,
+		AllowedUpdates: allUpdateTypes,
+	}
+	if params.GetUpdatesParams != nil {
+		getUpdatesParams = *params.GetUpdatesParams
+	}
+
+	return &Poller{
+		api:    api,
+		params: getUpdatesParams,
+		backoff: &RetryPolicy{
+			InitialBackoff:    params.InitialBackoff,
+			MaxBackoff:        params.MaxBackoff,
+			BackoffMultiplier: 2,
+			Jitter:            0.2,
+		},
+		onConflict:  params.OnConflict,
+		offsetStore: params.OffsetStore,
+		manualAck:   params.ManualAck,
+		next:        getUpdatesParams.Offset,
+		inFlight:    map[UpdateID]*Update{},
+		handled:     make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Starts receiving updates in background. Updates are passed to receiver
+// sequentially, sorted by UpdateID. Panics, if poller was already started.
+// Errors of OffsetStore are also passed to receiver, poller stops, if offset
+// can't be loaded.
+func (p *Poller) Start(receiver func(update *Update, err error)) {
+	ctx, cancel := context.WithCancel(p.api.Context())
+
+	p.mu.Lock()
+	if p.started {
+		p.mu.Unlock()
+		cancel()
+		panic("Poller.Start: poller is already started")
+	}
+	p.started = true
+	p.cancel = cancel
+	p.receiver = receiver
+	p.mu.Unlock()
+
+	go p.run(ctx, receiver)
+}
+
+func (p *Poller) run(ctx context.Context, receiver func(update *Update, err error)) {
+	defer close(p.done)
+
+	if p.offsetStore != nil {
+		offset, err := p.offsetStore.Load()
+		if err != nil {
+			err = fmt.Errorf("Poller: %w", err)
+			receiver(nil, err)
+
+			p.mu.Lock()
+			p.err = err
+			p.mu.Unlock()
+			return
+		}
+
+		p.mu.Lock()
+		if offset > p.next {
+			p.next = offset
+		}
+		p.mu.Unlock()
+
+		p.saveMu.Lock()
+		p.saved = offset
+		p.saveMu.Unlock()
+	}
+
+	api := p.api.WithContext(ctx)
+	params := p.params
+	failures := 0
+
+	for ctx.Err() == nil {
+		// Taken before request, so that updates handled during request are
+		// not missed
+		p.mu.Lock()
+		params.Offset = p.offset()
+		handled := p.handled
+		p.mu.Unlock()
+
+		updates, err := api.GetUpdates(&params)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+
+			receiver(nil, err)
+
+			if p.isFatal(api, err) {
+				p.mu.Lock()
+				p.err = err
+				p.mu.Unlock()
+				break
+			}
+
+			failures++
+			p.wait(ctx, p.nextWait(failures, err))
+			continue
+		}
+
+		failures = 0
+
+		p.mu.Lock()
+		next := p.next
+		p.mu.Unlock()
+
+		// Updates, which are not handled yet, are received again, as they are
+		// not confirmed, so they are skipped
+		newUpdates := make([]*Update, 0, len(updates))
+		for _, update := range SortUpdates(updates) {
+			if update.UpdateID >= next {
+				newUpdates = append(newUpdates, update)
+			}
+		}
+
+		if len(newUpdates) == 0 && len(updates) != 0 {
+			select {
+			case <-handled:
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		// Updates already received are passed to receiver, even if poller is
+		// shutting down, as they are not confirmed yet
+		for _, update := range newUpdates {
+			p.handle(update, receiver)
+		}
+	}
+}
+
+// Reports whether poller should stop because of err
+func (p *Poller) isFatal(api *API, err error) bool {
+	if IsUnauthorized(err) {
+		return true
+	}
+
+	if IsConflict(err) {
+		return p.onConflict == nil || !p.onConflict(api, err)
+	}
+
+	return false
+}
+
+// Returns time to wait after failed request
+func (p *Poller) nextWait(failures int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Parameters != nil && apiErr.Parameters.RetryAfter != 0 {
+		return time.Second * time.Duration(apiErr.Parameters.RetryAfter)
+	}
+
+	return p.backoff.backoff(failures)
+}
+
+// Waits until timeout exceeds or ctx is done
+func (p *Poller) wait(ctx context.Context, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+func (p *Poller) handle(update *Update, receiver func(update *Update, err error)) {
+	p.mu.Lock()
+	p.inFlight[update.UpdateID] = update
+	if update.UpdateID >= p.next {
+		p.next = update.UpdateID + 1
+	}
+	p.mu.Unlock()
+
+	receiver(update, nil)
+
+	if !p.manualAck {
+		p.Ack(update)
+	}
+}
+
+// Marks update as handled, if PollerParams.ManualAck is set. Otherwise
+// updates are marked as handled automatically, when receiver returns.
+//
+//	executor := telegrambot.NewUpdateExecutor(nil, func(update *telegrambot.Update, err error) {
+//		dispatcher.Receive(update, err)
+//		if update != nil {
+//			poller.Ack(update)
+//		}
+//	})
+//	poller.Start(executor.Receive)
+func (p *Poller) Ack(update *Update) {
+	p.mu.Lock()
+	if _, ok := p.inFlight[update.UpdateID]; !ok {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.inFlight, update.UpdateID)
+	close(p.handled)
+	p.handled = make(chan struct{})
+	offset := p.offset()
+	p.mu.Unlock()
+
+	p.save(offset)
+}
+
+// Saves offset to OffsetStore, if it is greater than saved one
+func (p *Poller) save(offset UpdateID) {
+	if p.offsetStore == nil {
+		return
+	}
+
+	p.saveMu.Lock()
+	defer p.saveMu.Unlock()
+
+	if offset <= p.saved {
+		return
+	}
+
+	err := p.offsetStore.Save(offset)
+	if err != nil {
+		p.receiver(nil, fmt.Errorf("Poller: %w", err))
+		return
+	}
+
+	p.saved = offset
+}
+
+// Returns offset of the next update to handle, which is UpdateID of the
+// earliest update being handled, or UpdateID of the last handled update plus
+// one. Updates before it are handled and can be confirmed.
+func (p *Poller) Offset() UpdateID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.offset()
+}
+
+func (p *Poller) offset() UpdateID {
+	offset := p.next
+	for updateID := range p.inFlight {
+		if updateID < offset {
+			offset = updateID
+		}
+	}
+
+	return offset
+}
+
+// Returns channel, which is closed, when poller stops receiving updates,
+// because of Shutdown or fatal error
+func (p *Poller) Done() <-chan struct{} {
+	return p.done
+}
+
+// Returns error, which stopped poller, e.x. 401 Unauthorized, or nil
+func (p *Poller) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+// Stops receiving updates, waits for handling of already received updates to
+// complete and confirms them to Telegram. Poller can't be started again. If
+// ctx is done earlier, returns InFlightUpdatesError with updates, which are
+// still being handled, and updates are not confirmed.
+func (p *Poller) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	started := p.started
+	cancel := p.cancel
+	p.mu.Unlock()
+
+	if !started {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return fmt.Errorf("Poller.Shutdown: %w", p.inFlightUpdatesError(ctx.Err()))
+	}
+
+	// With ManualAck updates can be handled after receiving is stopped
+	for {
+		p.mu.Lock()
+		inFlight := len(p.inFlight)
+		handled := p.handled
+		p.mu.Unlock()
+
+		if inFlight == 0 {
+			break
+		}
+
+		select {
+		case <-handled:
+		case <-ctx.Done():
+			return fmt.Errorf("Poller.Shutdown: %w", p.inFlightUpdatesError(ctx.Err()))
+		}
+	}
+
+	// Updates can't be confirmed, if poller was stopped by fatal error
+	offset := p.Offset()
+	if offset == 0 || p.Err() != nil {
+		return nil
+	}
+
+	// Updates are confirmed by requesting updates after them, limit and
+	// timeout make request return immediately
+	_, err := p.api.WithContext(ctx).GetUpdates(&GetUpdatesParams{
+		Offset:  offset,
+		Limit:   1,
+		Timeout: 0,
+	})
+	if err != nil {
+		return fmt.Errorf("Poller.Shutdown: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Poller) inFlightUpdatesError(err error) *InFlightUpdatesError {
+	p.mu.Lock()
+	inFlight := make([]*Update, 0, len(p.inFlight))
+	for _, update := range p.inFlight {
+		inFlight = append(inFlight, update)
+	}
+	p.mu.Unlock()
+
+	sort.Slice(inFlight, func(i, j int) bool {
+		return inFlight[i].UpdateID < inFlight[j].UpdateID
+	})
+
+	return &InFlightUpdatesError{
+		Updates: inFlight,
+		Err:     err,
+	}
+}
+
+// Returned by Poller.Shutdown, if updates are still being handled, when
+// context is done
+type InFlightUpdatesError struct {
+	// Updates being handled
+	Updates []*Update
+	// Error of context
+	Err error
+}
+
+func (e *InFlightUpdatesError) Error() string {
+	updateIDs := make([]UpdateID, len(e.Updates))
+	for i, update := range e.Updates {
+		updateIDs[i] = update.UpdateID
+	}
+
+	return fmt.Sprintf("updates %v are still being handled: %v", updateIDs, e.Err)
+}
+
+func (e *InFlightUpdatesError) Unwrap() error {
+	return e.Err
+}
+
+// Can be used as PollerParams.OnConflict. Deletes webhook, if conflict is
+// caused by it, so that polling is continued. Pending updates are kept.
+func DeleteWebhookOnConflict(api *API, err error) bool {
+	if !IsWebhookActive(err) {
+		return false
+	}
+
+	return api.DeleteWebhook(&DeleteWebhookParams{}) == nil
+}
@@ -0,0 +1,522 @@
+package telegrambot_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nickname76/telegrambot"
+	"github.com/nickname76/telegrambot/telegrambottest"
+)
+
+// Adds n messages to server, returns their updates
+func addMessages(server *telegrambottest.Server, n int) []*telegrambot.Update {
+	user := server.NewUser("Alice")
+	chat := server.PrivateChat(user)
+
+	updates := make([]*telegrambot.Update, n)
+	for i := range updates {
+		updates[i] = server.AddMessage(user, chat, "Hello")
+	}
+
+	return updates
+}
+
+// Returns the next update passed to receiver
+func nextUpdate(t *testing.T, received <-chan *telegrambot.Update) *telegrambot.Update {
+	t.Helper()
+
+	select {
+	case update := <-received:
+		return update
+	case <-time.After(5 * time.Second):
+		t.Fatal("update was not received")
+		return nil
+	}
+}
+
+func TestPollerShutdownConfirmsOffset(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	updates := addMessages(server, 3)
+	last := updates[len(updates)-1]
+
+	received := make(chan *telegrambot.Update, len(updates))
+	release := make(chan struct{})
+
+	poller := telegrambot.NewPoller(server.API(), nil)
+	poller.Start(func(update *telegrambot.Update, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		received <- update
+
+		// Poller must not request updates after the last one by itself
+		if update.UpdateID == last.UpdateID {
+			<-release
+		}
+	})
+
+	for range updates {
+		nextUpdate(t, received)
+	}
+
+	shutdownErr := make(chan error)
+	go func() {
+		shutdownErr <- poller.Shutdown(context.Background())
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if pending := server.PendingUpdates(); len(pending) == 0 {
+		t.Fatal("updates are confirmed before they are handled")
+	}
+	close(release)
+
+	err := <-shutdownErr
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pending := server.PendingUpdates(); len(pending) != 0 {
+		t.Fatalf("%v updates are not confirmed after shutdown", len(pending))
+	}
+
+	requests := server.RequestsOf("getUpdates")
+	params := requests[len(requests)-1].Params
+	if offset := params.Int64("offset"); offset != int64(last.UpdateID)+1 {
+		t.Errorf("offset of confirming request is %v, want %v", offset, last.UpdateID+1)
+	}
+	if limit := params.Int64("limit"); limit != 1 {
+		t.Errorf("limit of confirming request is %v, want 1", limit)
+	}
+	if timeout := params.Int64("timeout"); timeout != 0 {
+		t.Errorf("timeout of confirming request is %v, want 0", timeout)
+	}
+}
+
+func TestPollerShutdownReportsInFlightUpdates(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	updates := addMessages(server, 1)
+
+	received := make(chan *telegrambot.Update, 1)
+	release := make(chan struct{})
+	defer close(release)
+
+	poller := telegrambot.NewPoller(server.API(), nil)
+	poller.Start(func(update *telegrambot.Update, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		received <- update
+		<-release
+	})
+
+	nextUpdate(t, received)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := poller.Shutdown(ctx)
+
+	var inFlightErr *telegrambot.InFlightUpdatesError
+	if !errors.As(err, &inFlightErr) {
+		t.Fatalf("Shutdown returned %v, want InFlightUpdatesError", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown returned %v, want context.DeadlineExceeded", err)
+	}
+	if len(inFlightErr.Updates) != 1 || inFlightErr.Updates[0].UpdateID != updates[0].UpdateID {
+		t.Errorf("in-flight updates are %v, want update %v", inFlightErr.Updates, updates[0].UpdateID)
+	}
+
+	if pending := server.PendingUpdates(); len(pending) != 1 {
+		t.Errorf("update being handled is confirmed")
+	}
+}
+
+func TestPollerResumesFromOffsetStore(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	updates := addMessages(server, 3)
+	store := telegrambot.NewFileOffsetStore(filepath.Join(t.TempDir(), "offset"))
+
+	received := make(chan *telegrambot.Update, len(updates))
+	release := make(chan struct{})
+
+	// The first poller is stuck on the last update, as if process crashed
+	poller := telegrambot.NewPoller(server.API(), &telegrambot.PollerParams{
+		OffsetStore: store,
+	})
+
+	stuckPoller := poller
+	defer func() {
+		close(release)
+		<-stuckPoller.Done()
+	}()
+	poller.Start(func(update *telegrambot.Update, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		received <- update
+		if update.UpdateID == updates[2].UpdateID {
+			<-release
+		}
+	})
+
+	for range updates {
+		nextUpdate(t, received)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := poller.Shutdown(ctx)
+	if err == nil {
+		t.Fatal("Shutdown returned nil with update being handled")
+	}
+
+	offset, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != updates[2].UpdateID {
+		t.Fatalf("saved offset is %v, want %v", offset, updates[2].UpdateID)
+	}
+
+	// Telegram still has all updates, restarted poller skips handled ones
+	restarted := make(chan *telegrambot.Update, len(updates))
+	releaseRestarted := make(chan struct{})
+
+	poller = telegrambot.NewPoller(server.API(), &telegrambot.PollerParams{
+		OffsetStore: store,
+	})
+	poller.Start(func(update *telegrambot.Update, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		restarted <- update
+
+		// Shutdown is started before the next long polling request
+		<-releaseRestarted
+	})
+
+	update := nextUpdate(t, restarted)
+	if update.UpdateID != updates[2].UpdateID {
+		t.Errorf("restarted poller received update %v, want %v", update.UpdateID, updates[2].UpdateID)
+	}
+
+	shutdownErr := make(chan error)
+	go func() {
+		shutdownErr <- poller.Shutdown(context.Background())
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(releaseRestarted)
+
+	err = <-shutdownErr
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(restarted) != 0 {
+		t.Errorf("restarted poller received %v handled updates", len(restarted))
+	}
+
+	offset, err = store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != updates[2].UpdateID+1 {
+		t.Errorf("saved offset is %v, want %v", offset, updates[2].UpdateID+1)
+	}
+}
+
+func TestPollerManualAckHoldsOffset(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	updates := addMessages(server, 3)
+	store := telegrambot.NewMemoryOffsetStore()
+
+	received := make(chan *telegrambot.Update, len(updates))
+
+	poller := telegrambot.NewPoller(server.API(), &telegrambot.PollerParams{
+		OffsetStore: store,
+		ManualAck:   true,
+	})
+	poller.Start(func(update *telegrambot.Update, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		received <- update
+	})
+
+	for range updates {
+		nextUpdate(t, received)
+	}
+
+	checkOffset := func(want telegrambot.UpdateID) {
+		t.Helper()
+
+		if offset := poller.Offset(); offset != want {
+			t.Errorf("offset is %v, want %v", offset, want)
+		}
+
+		saved, err := store.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved != want {
+			t.Errorf("saved offset is %v, want %v", saved, want)
+		}
+	}
+
+	// The earlier update is still in flight
+	poller.Ack(updates[1])
+	checkOffset(updates[0].UpdateID)
+
+	poller.Ack(updates[0])
+	checkOffset(updates[2].UpdateID)
+
+	time.Sleep(50 * time.Millisecond)
+	if pending := server.PendingUpdates(); len(pending) != 1 || pending[0].UpdateID != updates[2].UpdateID {
+		t.Errorf("pending updates are %v, want update %v", pending, updates[2].UpdateID)
+	}
+
+	poller.Ack(updates[2])
+	checkOffset(updates[2].UpdateID + 1)
+
+	err := poller.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pending := server.PendingUpdates(); len(pending) != 0 {
+		t.Errorf("%v updates are not confirmed after shutdown", len(pending))
+	}
+}
+
+// Starts poller and returns channels of updates and errors passed to
+// receiver
+func startPoller(api *telegrambot.API, params *telegrambot.PollerParams) (*telegrambot.Poller, <-chan *telegrambot.Update, <-chan error) {
+	received := make(chan *telegrambot.Update, 10)
+	errs := make(chan error, 10)
+
+	poller := telegrambot.NewPoller(api, params)
+	poller.Start(func(update *telegrambot.Update, err error) {
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		received <- update
+	})
+
+	return poller, received, errs
+}
+
+// Returns the next error passed to receiver and time it was received
+func nextError(t *testing.T, errs <-chan error) (error, time.Time) {
+	t.Helper()
+
+	select {
+	case err := <-errs:
+		return err, time.Now()
+	case <-time.After(5 * time.Second):
+		t.Fatal("error was not received")
+		return nil, time.Time{}
+	}
+}
+
+// Checks that poller stops with error, which matches is
+func checkPollerStopped(t *testing.T, poller *telegrambot.Poller, is func(err error) bool) {
+	t.Helper()
+
+	select {
+	case <-poller.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("poller is not stopped")
+	}
+
+	if err := poller.Err(); !is(err) {
+		t.Errorf("poller stopped with %v", err)
+	}
+}
+
+func TestPollerBackoff(t *testing.T) {
+	const (
+		initialBackoff = 100 * time.Millisecond
+		maxBackoff     = 200 * time.Millisecond
+		// Time of request and scheduling
+		slack = 50 * time.Millisecond
+	)
+
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	// Pending update makes failing requests return at once
+	addMessages(server, 1)
+	for i := 0; i < 4; i++ {
+		server.FailNext("getUpdates", 500, "Internal Server Error", nil)
+	}
+
+	poller, received, errs := startPoller(server.API(), &telegrambot.PollerParams{
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+	})
+	defer poller.Shutdown(context.Background())
+
+	_, last := nextError(t, errs)
+
+	// Backoff doubles up to maximum, it is randomized by ±20%
+	for _, backoff := range []time.Duration{initialBackoff, maxBackoff, maxBackoff} {
+		_, now := nextError(t, errs)
+		wait := now.Sub(last)
+		last = now
+
+		if wait < backoff*8/10 || wait > backoff*12/10+slack {
+			t.Errorf("poller waited %v after failure, want %v", wait, backoff)
+		}
+	}
+
+	nextUpdate(t, received)
+	if wait := time.Since(last); wait > maxBackoff*12/10+slack {
+		t.Errorf("poller waited %v after failure, want %v", wait, maxBackoff)
+	}
+}
+
+func TestPollerRetryAfter(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	addMessages(server, 1)
+	server.FailNext("getUpdates", 429, "Too Many Requests: retry after 1", &telegrambot.ResponseParameters{
+		RetryAfter: 1,
+	})
+
+	// API doesn't repeat request itself, so poller waits for retry_after
+	api := server.API()
+	api.RetryPolicy = &telegrambot.RetryPolicy{MaxAttempts: 1}
+
+	poller, received, errs := startPoller(api, &telegrambot.PollerParams{
+		InitialBackoff: 10 * time.Millisecond,
+	})
+	defer poller.Shutdown(context.Background())
+
+	err, failed := nextError(t, errs)
+	if !telegrambot.IsTooManyRequests(err) {
+		t.Errorf("poller received %v, want 429 error", err)
+	}
+
+	nextUpdate(t, received)
+	if wait := time.Since(failed); wait < time.Second {
+		t.Errorf("poller waited %v, want retry_after of 1 second", wait)
+	}
+}
+
+func TestPollerStopsOnUnauthorized(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	api := server.API()
+	api.Token = "123456:wrong-token"
+
+	poller, _, errs := startPoller(api, nil)
+	defer poller.Shutdown(context.Background())
+
+	err, _ := nextError(t, errs)
+	if !telegrambot.IsUnauthorized(err) {
+		t.Errorf("poller received %v, want 401 error", err)
+	}
+
+	checkPollerStopped(t, poller, telegrambot.IsUnauthorized)
+}
+
+func TestPollerStopsOnConflict(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	err := server.API().SetWebhook(&telegrambot.SetWebhookParams{
+		URL: "https://example.com/webhook",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	poller, _, errs := startPoller(server.API(), nil)
+	defer poller.Shutdown(context.Background())
+
+	err, _ = nextError(t, errs)
+	if !telegrambot.IsWebhookActive(err) {
+		t.Errorf("poller received %v, want 409 error", err)
+	}
+
+	checkPollerStopped(t, poller, telegrambot.IsConflict)
+
+	if server.WebhookURL() == "" {
+		t.Error("webhook is deleted without OnConflict")
+	}
+}
+
+func TestPollerDeleteWebhookOnConflict(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	err := server.API().SetWebhook(&telegrambot.SetWebhookParams{
+		URL: "https://example.com/webhook",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates := addMessages(server, 1)
+
+	poller, received, errs := startPoller(server.API(), &telegrambot.PollerParams{
+		InitialBackoff: 10 * time.Millisecond,
+		OnConflict:     telegrambot.DeleteWebhookOnConflict,
+	})
+	defer poller.Shutdown(context.Background())
+
+	err, _ = nextError(t, errs)
+	if !telegrambot.IsWebhookActive(err) {
+		t.Errorf("poller received %v, want 409 error", err)
+	}
+
+	if update := nextUpdate(t, received); update.UpdateID != updates[0].UpdateID {
+		t.Errorf("received update %v, want %v", update.UpdateID, updates[0].UpdateID)
+	}
+
+	if url := server.WebhookURL(); url != "" {
+		t.Errorf("webhook %q is not deleted", url)
+	}
+
+	select {
+	case <-poller.Done():
+		t.Errorf("poller stopped with %v", poller.Err())
+	default:
+	}
+
+	// Other conflicts are not resolved by deleting webhook
+	if telegrambot.DeleteWebhookOnConflict(server.API(), &telegrambot.APIError{
+		ErrorCode:   409,
+		Description: "Conflict: terminated by other getUpdates request; make sure that only one bot instance is running",
+	}) {
+		t.Error("DeleteWebhookOnConflict continues polling on conflict with other poller")
+	}
+}
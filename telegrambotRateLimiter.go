@@ -0,0 +1,237 @@
+package telegrambot
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits rate of outgoing API requests. Set it to API.Limiter to make all
+// requests wait for it before sending.
+type Limiter interface {
+	// Blocks until request may be sent, or returns ctx error if ctx is done
+	// earlier. chatID is value of chat_id request field, or nil for requests
+	// without it.
+	Wait(ctx context.Context, method string, chatID ChatIDOrUsername) error
+}
+
+// Number of requests allowed during period
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+type RateLimiterParams struct {
+	// Optional. Limit for all requests of the bot. Defaults to 30 requests per
+	// second.
+	Global RateLimit
+	// Optional. Limit for requests to a single private chat. Defaults to 1
+	// request per second.
+	PrivateChat RateLimit
+	// Optional. Limit for requests to a single group, supergroup or channel.
+	// Defaults to 20 requests per minute.
+	GroupChat RateLimit
+	// Optional. Reports whether requests of method are limited per chat.
+	// Defaults to IsMessageSendingMethod.
+	ChatLimitedMethod func(method string) bool
+}
+
+// Limiter, which follows Telegram broadcasting limits, using token buckets.
+// Requests sending messages are limited per chat and globally, other requests
+// only globally. getUpdates requests are not limited.
+// https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this
+//
+// Chat type is derived from chat identifier: positive identifiers are treated
+// as private chats, negative identifiers and usernames as groups and channels.
+type RateLimiter struct {
+	params RateLimiterParams
+
+	mu        sync.Mutex
+	global    *tokenBucket
+	chats     map[ChatIDOrUsername]*tokenBucket
+	lastSweep time.Time
+}
+
+// Creates RateLimiter. params may be nil to use default limits.
+//
+//	api.Limiter = telegrambot.NewRateLimiter(nil)
+func NewRateLimiter(params *RateLimiterParams) *RateLimiter {
+	rl := &RateLimiter{
+		chats:     map[ChatIDOrUsername]*tokenBucket{},
+		lastSweep: time.Now(),
+	}
+
+	if params != nil {
+		rl.params = *params
+	}
+
+	if rl.params.Global.Requests <= 0 || rl.params.Global.Period <= 0 {
+		rl.params.Global = RateLimit{Requests: 30, Period: time.Second}
+	}
+	if rl.params.PrivateChat.Requests <= 0 || rl.params.PrivateChat.Period <= 0 {
+		rl.params.PrivateChat = RateLimit{Requests: 1, Period: time.Second}
+	}
+	if rl.params.GroupChat.Requests <= 0 || rl.params.GroupChat.Period <= 0 {
+		rl.params.GroupChat = RateLimit{Requests: 20, Period: time.Minute}
+	}
+	if rl.params.ChatLimitedMethod == nil {
+		rl.params.ChatLimitedMethod = IsMessageSendingMethod
+	}
+
+	rl.global = newTokenBucket(rl.params.Global, rl.lastSweep)
+
+	return rl
+}
+
+func (rl *RateLimiter) Wait(ctx context.Context, method string, chatID ChatIDOrUsername) error {
+	if method == "getUpdates" {
+		return nil
+	}
+
+	now := time.Now()
+
+	rl.mu.Lock()
+
+	rl.sweep(now)
+
+	var chatBucket *tokenBucket
+	if chatID != nil && rl.params.ChatLimitedMethod(method) {
+		chatBucket = rl.chats[chatID]
+		if chatBucket == nil {
+			chatBucket = newTokenBucket(rl.chatRateLimit(chatID), now)
+			rl.chats[chatID] = chatBucket
+		}
+	}
+
+	// Chat bucket is reserved first, so that request waiting for a slow chat
+	// reserves global capacity only for the moment it will be sent
+	var delay time.Duration
+	if chatBucket != nil {
+		delay = chatBucket.reserve(now, now)
+	}
+	globalDelay := rl.global.reserve(now, now.Add(delay))
+	if globalDelay > delay {
+		delay = globalDelay
+	}
+
+	rl.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	err := sleepContext(ctx, delay)
+	if err != nil {
+		rl.mu.Lock()
+		rl.global.release()
+		if chatBucket != nil {
+			chatBucket.release()
+		}
+		rl.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// Reports whether method sends messages, which Telegram limits per chat:
+// send* methods except sendChatAction, forwardMessage and copyMessage
+func IsMessageSendingMethod(method string) bool {
+	method = strings.ToLower(method)
+
+	switch method {
+	case "sendchataction":
+		return false
+	case "forwardmessage", "copymessage":
+		return true
+	}
+
+	return strings.HasPrefix(method, "send")
+}
+
+func (rl *RateLimiter) chatRateLimit(chatID ChatIDOrUsername) RateLimit {
+	if id, ok := chatID.(ChatID); ok && id > 0 {
+		return rl.params.PrivateChat
+	}
+
+	return rl.params.GroupChat
+}
+
+// Removes buckets of chats, which were not used long enough to be full again
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < time.Minute {
+		return
+	}
+	rl.lastSweep = now
+
+	for chatID, bucket := range rl.chats {
+		if bucket.isFull(now) {
+			delete(rl.chats, chatID)
+		}
+	}
+}
+
+// Token bucket, holding up to burst tokens and refilled with rate tokens per
+// second. Tokens may go negative, which means, that requests are reserved for
+// the future.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(limit.Requests) / limit.Period.Seconds(),
+		burst:  float64(limit.Requests),
+		tokens: float64(limit.Requests),
+		last:   now,
+	}
+}
+
+func (tb *tokenBucket) refill(now time.Time) {
+	if now.After(tb.last) {
+		tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+		if tb.tokens > tb.burst {
+			tb.tokens = tb.burst
+		}
+		tb.last = now
+	}
+}
+
+// Takes a token for request not earlier than at, returns delay from now, after
+// which request may be sent
+func (tb *tokenBucket) reserve(now time.Time, at time.Time) time.Duration {
+	tb.refill(now)
+
+	// Tokens which will be refilled until at are counted as available
+	available := tb.tokens + at.Sub(now).Seconds()*tb.rate
+	if available > tb.burst {
+		available = tb.burst
+	}
+
+	tb.tokens -= 1
+	available -= 1
+
+	if available >= 0 {
+		return at.Sub(now)
+	}
+
+	return at.Sub(now) + time.Duration(-available/tb.rate*float64(time.Second))
+}
+
+// Gives back token of canceled reservation
+func (tb *tokenBucket) release() {
+	tb.tokens += 1
+	if tb.tokens > tb.burst {
+		tb.tokens = tb.burst
+	}
+}
+
+func (tb *tokenBucket) isFull(now time.Time) bool {
+	tb.refill(now)
+	return tb.tokens >= tb.burst
+}
@@ -0,0 +1,72 @@
+package telegrambot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nickname76/telegrambot"
+)
+
+func newTestRateLimiter() *telegrambot.RateLimiter {
+	return telegrambot.NewRateLimiter(&telegrambot.RateLimiterParams{
+		PrivateChat: telegrambot.RateLimit{Requests: 1, Period: 200 * time.Millisecond},
+		GroupChat:   telegrambot.RateLimit{Requests: 2, Period: 200 * time.Millisecond},
+	})
+}
+
+// Returns time, which waiting for limiter took for requests
+func waitRequests(t *testing.T, rl *telegrambot.RateLimiter, chatID telegrambot.ChatIDOrUsername, methods ...string) time.Duration {
+	t.Helper()
+
+	start := time.Now()
+	for _, method := range methods {
+		err := rl.Wait(context.Background(), method, chatID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return time.Since(start)
+}
+
+func TestRateLimiterLimitsMessagesPerChat(t *testing.T) {
+	rl := newTestRateLimiter()
+
+	elapsed := waitRequests(t, rl, telegrambot.ChatID(1), "sendMessage", "sendMessage")
+	if elapsed < 150*time.Millisecond {
+		t.Fatalf("second message to private chat was not delayed, waited %v", elapsed)
+	}
+
+	elapsed = waitRequests(t, rl, telegrambot.ChatID(-1), "sendMessage", "copyMessage", "forwardMessage")
+	if elapsed < 50*time.Millisecond {
+		t.Fatalf("third message to group was not delayed, waited %v", elapsed)
+	}
+}
+
+func TestRateLimiterDoesNotLimitOtherMethodsPerChat(t *testing.T) {
+	rl := newTestRateLimiter()
+
+	elapsed := waitRequests(t, rl, telegrambot.ChatID(1), "getChat", "getChat", "sendChatAction", "sendMessage")
+	if elapsed > 50*time.Millisecond {
+		t.Fatalf("requests were delayed for %v", elapsed)
+	}
+
+	elapsed = waitRequests(t, rl, telegrambot.ChatID(-1), "banChatMember", "deleteMessage", "editMessageText", "getChatMember")
+	if elapsed > 50*time.Millisecond {
+		t.Fatalf("requests were delayed for %v", elapsed)
+	}
+}
+
+func TestRateLimiterCanceled(t *testing.T) {
+	rl := newTestRateLimiter()
+	waitRequests(t, rl, telegrambot.ChatID(1), "sendMessage")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := rl.Wait(ctx, "sendMessage", telegrambot.ChatID(1))
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package telegrambot
+
+import (
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Fields added to Bot API after version supported by this library are not
+// decoded into Update and Message, so JSON they were decoded from is kept, to
+// access such fields with RawField.
+
+// Frozen once, as freezing config drops its cache of decoders, and Update
+// and Message are decoded recursively, e.x. Message.ReplyToMessage
+var rawJSONConfig = jsoniter.Config{
+	OnlyTaggedField:               true,
+	ObjectFieldMustBeSimpleString: true,
+	CaseSensitive:                 true,
+}.Froze()
+
+type updateFields Update
+
+func (u *Update) UnmarshalJSON(data []byte) error {
+	err := rawJSONConfig.Unmarshal(data, (*updateFields)(u))
+	if err != nil {
+		return err
+	}
+
+	u.raw = append([]byte(nil), data...)
+
+	return nil
+}
+
+// Returns JSON, from which update was decoded, including fields unknown to
+// this library. Returns nil, if update was not decoded from JSON.
+func (u *Update) RawJSON() []byte {
+	return u.raw
+}
+
+// Returns JSON of update field at path, which consists of object keys
+// (string) and array indexes (int). Returns nil, if field is not present.
+//
+//	reaction := update.RawField("message_reaction", "new_reaction", 0, "emoji")
+func (u *Update) RawField(path ...any) []byte {
+	return rawField(u.raw, path)
+}
+
+type messageFields Message
+
+func (msg *Message) UnmarshalJSON(data []byte) error {
+	err := rawJSONConfig.Unmarshal(data, (*messageFields)(msg))
+	if err != nil {
+		return err
+	}
+
+	msg.raw = append([]byte(nil), data...)
+
+	return nil
+}
+
+// Returns JSON, from which message was decoded, including fields unknown to
+// this library. Returns nil, if message was not decoded from JSON.
+func (msg *Message) RawJSON() []byte {
+	return msg.raw
+}
+
+// Returns JSON of message field at path, which consists of object keys
+// (string) and array indexes (int). Returns nil, if field is not present.
+//
+//	threadID := msg.RawField("message_thread_id")
+func (msg *Message) RawField(path ...any) []byte {
+	return rawField(msg.raw, path)
+}
+
+func rawField(raw []byte, path []any) []byte {
+	if raw == nil {
+		return nil
+	}
+
+	field := jsoniter.Get(raw, path...)
+	if field.LastError() != nil || field.ValueType() == jsoniter.InvalidValue {
+		return nil
+	}
+
+	fieldJSON, err := rawJSONConfig.Marshal(field)
+	if err != nil {
+		return nil
+	}
+
+	return fieldJSON
+}
@@ -0,0 +1,80 @@
+package telegrambot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Same config, as used for decoding API responses
+var testJSONConfig = jsoniter.Config{
+	OnlyTaggedField:               true,
+	ObjectFieldMustBeSimpleString: true,
+	CaseSensitive:                 true,
+}.Froze()
+
+// Response of getUpdates with 50 messages, each replying to another message
+func getUpdatesResponseJSON() []byte {
+	updates := make([]string, 50)
+	for i := range updates {
+		updates[i] = fmt.Sprintf(`{"update_id":%v,"message":{"message_id":%v,"date":1,`+
+			`"from":{"id":1,"is_bot":false,"first_name":"User"},`+
+			`"chat":{"id":1,"type":"private","first_name":"User"},"text":"text",`+
+			`"reply_to_message":{"message_id":1,"date":1,"chat":{"id":1,"type":"private"},"text":"reply"}}}`, i+1, i+2)
+	}
+
+	return []byte(`{"ok":true,"result":[` + strings.Join(updates, ",") + `]}`)
+}
+
+func decodeGetUpdatesResponse(tb testing.TB, data []byte) []*Update {
+	var updates []*Update
+	err := testJSONConfig.Unmarshal(data, &Response{Result: &updates})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return updates
+}
+
+func TestUpdateRawJSON(t *testing.T) {
+	updates := decodeGetUpdatesResponse(t, getUpdatesResponseJSON())
+	if len(updates) != 50 {
+		t.Fatalf("decoded %v updates, expected 50", len(updates))
+	}
+
+	msg := updates[0].Message
+	if msg.ReplyToMessage == nil || string(msg.ReplyToMessage.RawField("text")) != `"reply"` {
+		t.Fatalf("reply to message raw JSON is not kept: %s", msg.ReplyToMessage.RawJSON())
+	}
+
+	if string(updates[0].RawField("message", "chat", "id")) != "1" {
+		t.Fatalf("unexpected raw field: %s", updates[0].RawField("message", "chat", "id"))
+	}
+}
+
+// Decoders must be cached between decodes, otherwise decoding allocates
+// megabytes for every response
+func TestUpdateDecodeAllocs(t *testing.T) {
+	data := getUpdatesResponseJSON()
+	decodeGetUpdatesResponse(t, data)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		decodeGetUpdatesResponse(t, data)
+	})
+	if allocs > 5000 {
+		t.Fatalf("decoding 50 updates made %v allocations", allocs)
+	}
+}
+
+func BenchmarkDecodeUpdates(b *testing.B) {
+	data := getUpdatesResponseJSON()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		decodeGetUpdatesResponse(b, data)
+	}
+}
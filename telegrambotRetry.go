@@ -0,0 +1,176 @@
+package telegrambot
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Policy of repeating failed API requests. Requests exceeding flood control are
+// repeated after the time specified by Telegram in retry_after, other
+// retriable failures are repeated with exponential backoff.
+//
+//	api.RetryPolicy = telegrambot.NewRetryPolicy()
+type RetryPolicy struct {
+	// Optional. Maximum number of attempts, including the first one. Zero means
+	// no limit.
+	MaxAttempts int
+	// Optional. Maximum total time of waiting between attempts of a single
+	// request. Request is not repeated, if waiting would exceed it. Zero means
+	// no limit.
+	MaxTotalWait time.Duration
+	// Optional. Backoff before the first repeat of failure without
+	// retry_after. Defaults to 1 second.
+	InitialBackoff time.Duration
+	// Optional. Maximum backoff between attempts of failures without
+	// retry_after. Defaults to 30 seconds.
+	MaxBackoff time.Duration
+	// Optional. Factor by which backoff grows after each attempt. Defaults to
+	// 2.
+	BackoffMultiplier float64
+	// Optional. Randomization factor of backoff, between 0 and 1. For example,
+	// 0.2 means, that backoff is randomly changed up to ±20%.
+	Jitter float64
+	// Optional. Reports whether failed request should be repeated. Defaults to
+	// IsRetriableError.
+	IsRetriable func(err error) bool
+	// Optional. Called before waiting for each repeat of a request, e.x. for
+	// logging and metrics.
+	OnRetry func(info *RetryInfo)
+}
+
+// Information about repeat of a failed request, passed to RetryPolicy.OnRetry
+type RetryInfo struct {
+	// Name of Bot API method, e.x. "sendMessage"
+	Method string
+	// Number of the failed attempt, starting from 1
+	Attempt int
+	// Error of the failed attempt
+	Err error
+	// Time to wait before the next attempt
+	Wait time.Duration
+}
+
+// Returns policy, which repeats flood control failures, network errors and
+// Telegram server errors up to 5 attempts and 1 minute of total waiting.
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:       5,
+		MaxTotalWait:      time.Minute,
+		InitialBackoff:    time.Second,
+		MaxBackoff:        time.Second * 30,
+		BackoffMultiplier: 2,
+		Jitter:            0.2,
+	}
+}
+
+// Used, when API.RetryPolicy is not set. Repeats requests exceeding flood
+// control after retry_after without limits, as this library always did.
+var legacyRetryPolicy = &RetryPolicy{
+	IsRetriable: hasRetryAfter,
+}
+
+// Reports whether Telegram told to repeat request after some time
+func hasRetryAfter(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Parameters != nil && apiErr.Parameters.RetryAfter != 0
+}
+
+// Reports whether failed request is worth repeating. Returns true for flood
+// control errors (429), Telegram server errors (5xx), responses which are not
+// Bot API responses (e.x. "502 Bad Gateway" pages of proxies), and network
+// errors. Returns false for other Bot API errors, Bot API responses which
+// can't be decoded, chat migrations and context errors.
+func IsRetriableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Request was performed, if Telegram responded, so repeating it could
+	// e.x. send message again
+	var decodeErr *ResponseDecodeError
+	if errors.As(err, &decodeErr) {
+		return !decodeErr.APIResponse
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Parameters != nil && apiErr.Parameters.MigrateToChatID != 0 {
+			return false
+		}
+
+		return apiErr.ErrorCode == 429 || apiErr.ErrorCode >= 500
+	}
+
+	return true
+}
+
+// Returns time to wait before the next attempt, and false if request should
+// not be repeated
+func (rp *RetryPolicy) nextWait(attempt int, totalWait time.Duration, err error) (time.Duration, bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+
+	if _, ok := IsChatMigrated(err); ok {
+		return 0, false
+	}
+
+	isRetriable := rp.IsRetriable
+	if isRetriable == nil {
+		isRetriable = IsRetriableError
+	}
+
+	if !isRetriable(err) {
+		return 0, false
+	}
+
+	if rp.MaxAttempts > 0 && attempt >= rp.MaxAttempts {
+		return 0, false
+	}
+
+	var wait time.Duration
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Parameters != nil && apiErr.Parameters.RetryAfter != 0 {
+		wait = time.Second * time.Duration(apiErr.Parameters.RetryAfter)
+	} else {
+		wait = rp.backoff(attempt)
+	}
+
+	if rp.MaxTotalWait > 0 && totalWait+wait > rp.MaxTotalWait {
+		return 0, false
+	}
+
+	return wait, true
+}
+
+func (rp *RetryPolicy) backoff(attempt int) time.Duration {
+	initialBackoff := rp.InitialBackoff
+	if initialBackoff <= 0 {
+		initialBackoff = time.Second
+	}
+
+	maxBackoff := rp.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = time.Second * 30
+	}
+
+	multiplier := rp.BackoffMultiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	backoff := float64(initialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if backoff > float64(maxBackoff) {
+		backoff = float64(maxBackoff)
+	}
+
+	if rp.Jitter > 0 {
+		backoff += backoff * rp.Jitter * (rand.Float64()*2 - 1)
+	}
+
+	return time.Duration(backoff)
+}
@@ -0,0 +1,114 @@
+package telegrambot_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nickname76/telegrambot"
+)
+
+// Returns API, which responds to every request with respBody, and counter of
+// performed requests
+func newStaticResponseAPI(respBody string) (*telegrambot.API, *int) {
+	requests := 0
+	api := &telegrambot.API{
+		Token:       testToken,
+		EndpointURL: telegrambot.DefaultAPIEndpointURL,
+		HttpDoRequestContext: func(ctx context.Context, method string, url string, headers map[string]string, body []byte) ([]byte, error) {
+			requests++
+			return []byte(respBody), nil
+		},
+		RetryPolicy: telegrambot.NewRetryPolicy(),
+	}
+	api.RetryPolicy.InitialBackoff = time.Millisecond
+
+	return api, &requests
+}
+
+func TestRetryDecodeErrorOfAPIResponse(t *testing.T) {
+	api, requests := newStaticResponseAPI(`{"ok":true,"result":"not a message"}`)
+
+	_, err := api.SendMessage(&telegrambot.SendMessageParams{
+		ChatID: telegrambot.ChatID(1),
+		Text:   "text",
+	})
+
+	var decodeErr *telegrambot.ResponseDecodeError
+	if !errors.As(err, &decodeErr) || !decodeErr.APIResponse {
+		t.Fatalf("expected ResponseDecodeError of API response, got %v", err)
+	}
+	if *requests != 1 {
+		t.Fatalf("request was sent %v times, expected once", *requests)
+	}
+}
+
+func TestRetryDecodeErrorOfProxyPage(t *testing.T) {
+	api, requests := newStaticResponseAPI(`<html><body>502 Bad Gateway</body></html>`)
+
+	_, err := api.SendMessage(&telegrambot.SendMessageParams{
+		ChatID: telegrambot.ChatID(1),
+		Text:   "text",
+	})
+
+	var decodeErr *telegrambot.ResponseDecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.APIResponse {
+		t.Fatalf("expected ResponseDecodeError of non API response, got %v", err)
+	}
+	if *requests != api.RetryPolicy.MaxAttempts {
+		t.Fatalf("request was sent %v times, expected %v", *requests, api.RetryPolicy.MaxAttempts)
+	}
+}
+
+// Returns API without RetryPolicy, which responds with respBodies in order,
+// and counter of performed requests
+func newLegacyRetryAPI(respBodies ...string) (*telegrambot.API, *int) {
+	requests := 0
+	api := &telegrambot.API{
+		Token:       testToken,
+		EndpointURL: telegrambot.DefaultAPIEndpointURL,
+		HttpDoRequestContext: func(ctx context.Context, method string, url string, headers map[string]string, body []byte) ([]byte, error) {
+			respBody := respBodies[requests]
+			requests++
+			return []byte(respBody), nil
+		},
+	}
+
+	return api, &requests
+}
+
+func TestLegacyRetryAfter(t *testing.T) {
+	api, requests := newLegacyRetryAPI(
+		`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`,
+		`{"ok":true,"result":true}`,
+	)
+
+	start := time.Now()
+
+	err := api.DeleteWebhook(&telegrambot.DeleteWebhookParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *requests != 2 {
+		t.Fatalf("request was sent %v times, expected twice", *requests)
+	}
+	if wait := time.Since(start); wait < time.Second {
+		t.Fatalf("request was repeated after %v, expected retry_after of 1 second", wait)
+	}
+}
+
+func TestLegacyRetryWithoutRetryAfter(t *testing.T) {
+	api, requests := newLegacyRetryAPI(
+		`{"ok":false,"error_code":429,"description":"Too Many Requests"}`,
+		`{"ok":true,"result":true}`,
+	)
+
+	err := api.DeleteWebhook(&telegrambot.DeleteWebhookParams{})
+	if !telegrambot.IsTooManyRequests(err) {
+		t.Fatalf("expected 429 error, got %v", err)
+	}
+	if *requests != 1 {
+		t.Fatalf("request was sent %v times, expected once", *requests)
+	}
+}
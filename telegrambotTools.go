@@ -1,6 +1,7 @@
 package telegrambot
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -10,58 +11,51 @@ import (
 	"github.com/nickname76/repeater"
 )
 
+// All types of updates. By default telegram sends most types of updates, but
+// not all, so they are specified explicitly.
+var allUpdateTypes = []UpdateType{
+	UpdateTypeMessage,
+	UpdateTypeEditedMessage,
+	UpdateTypeChannelPost,
+	UpdateTypeEditedChannelPost,
+	UpdateTypeInlineQuery,
+	UpdateTypeChosenInlineResult,
+	UpdateTypeCallbackQuery,
+	UpdateTypeShippingQuery,
+	UpdateTypePreCheckoutQuery,
+	UpdateTypePoll,
+	UpdateTypePollAnswer,
+	UpdateTypeMyChatMember,
+	UpdateTypeChatMember,
+	UpdateTypeChatJoinRequest,
+}
+
 // Starts receiving all possible updates from Telegram
 func StartReceivingUpdates(api *API, receiver func(update *Update, err error)) (stop func()) {
-	// By default telegram sends most types of updates, but not all, so here are
-	// specified all types of updates
 	return StartReceivingUpdatesWithParams(api, GetUpdatesParams{
-		Timeout: 2,
-		AllowedUpdates: []UpdateType{
-			UpdateTypeMessage,
-			UpdateTypeEditedMessage,
-			UpdateTypeChannelPost,
-			UpdateTypeEditedChannelPost,
-			UpdateTypeInlineQuery,
-			UpdateTypeChosenInlineResult,
-			UpdateTypeCallbackQuery,
-			UpdateTypeShippingQuery,
-			UpdateTypePreCheckoutQuery,
-			UpdateTypePoll,
-			UpdateTypePollAnswer,
-			UpdateTypeMyChatMember,
-			UpdateTypeChatMember,
-			UpdateTypeChatJoinRequest,
-		},
+		Timeout:        2,
+		AllowedUpdates: allUpdateTypes,
 	}, receiver)
 }
 
-// Starts receiving updates from Telegram with custom parameters. You should not
-// pass offset field in params.
+// Starts receiving updates from Telegram with custom parameters. Offset field
+// in params is used only for the first request. stop waits for updates being
+// handled and confirms them to Telegram. Errors are passed to receiver, failed
+// requests are repeated with backoff, receiving stops on 401 Unauthorized and
+// 409 Conflict errors. See Poller for more control.
 func StartReceivingUpdatesWithParams(api *API, params GetUpdatesParams, receiver func(update *Update, err error)) (stop func()) {
-	stop = repeater.StartRepeater(0, func() {
-		updates, err := api.GetUpdates(&params)
-		if err != nil {
-			receiver(nil, err)
-			return
-		}
-
-		if len(updates) == 0 {
-			return
-		}
-
-		updates = SortUpdates(updates)
-
-		for _, update := range updates {
-			receiver(update, nil)
-		}
-
-		params.Offset = updates[len(updates)-1].UpdateID + 1
+	poller := NewPoller(api, &PollerParams{
+		GetUpdatesParams: &params,
 	})
+	poller.Start(receiver)
 
-	return stop
+	return func() {
+		poller.Shutdown(context.Background())
+	}
 }
 
-// Use to parse body from Webhook request, used to receive updates
+// Use to parse body from Webhook request, used to receive updates. See
+// WebhookHandler for complete handler of webhook requests.
 func ParseWebhookUpdate(body []byte) (*Update, error) {
 	jsoniterCfg := jsoniter.Config{
 		OnlyTaggedField:               true,
@@ -136,6 +130,13 @@ func StartChatAction(api API, params *SendChatActionParams) (stop func(), err er
 // If command not found, return nothing.
 // If command is not placed at the start of a message, returns nothing.
 func ParseMessageCommand(msg *Message) (command string, args string) {
+	command, _, args = parseMessageCommand(msg)
+	return
+}
+
+// Returns command name from msg, and username of bot, which command is
+// addressed to, e.x. "/start@my_bot", if it is specified
+func parseMessageCommand(msg *Message) (command string, username string, args string) {
 	var (
 		text         string
 		textEntities []*MessageEntity
@@ -161,6 +162,7 @@ func ParseMessageCommand(msg *Message) (command string, args string) {
 
 		usernameIndex := strings.Index(command, "@")
 		if usernameIndex != -1 {
+			username = command[usernameIndex+1:]
 			command = command[:usernameIndex]
 		}
 
@@ -339,3 +339,23 @@ const (
 	StickerTypeMask        StickerType = "mask"
 	StickerTypeCustomEmoji StickerType = "custom_emoji"
 )
+
+// Type of media or other content of message, see FilterMediaType
+type MessageMediaType string
+
+const (
+	MessageMediaTypeAnimation MessageMediaType = "animation"
+	MessageMediaTypeAudio     MessageMediaType = "audio"
+	MessageMediaTypeDocument  MessageMediaType = "document"
+	MessageMediaTypePhoto     MessageMediaType = "photo"
+	MessageMediaTypeSticker   MessageMediaType = "sticker"
+	MessageMediaTypeVideo     MessageMediaType = "video"
+	MessageMediaTypeVideoNote MessageMediaType = "video_note"
+	MessageMediaTypeVoice     MessageMediaType = "voice"
+	MessageMediaTypeContact   MessageMediaType = "contact"
+	MessageMediaTypeDice      MessageMediaType = "dice"
+	MessageMediaTypeGame      MessageMediaType = "game"
+	MessageMediaTypePoll      MessageMediaType = "poll"
+	MessageMediaTypeVenue     MessageMediaType = "venue"
+	MessageMediaTypeLocation  MessageMediaType = "location"
+)
@@ -0,0 +1,147 @@
+package telegrambot
+
+// Returns type of update, which is name of its non-empty optional field, e.x.
+// UpdateTypeCallbackQuery. Returns empty string for types of updates, which
+// are not supported by this library.
+func (u *Update) Kind() UpdateType {
+	switch {
+	case u.Message != nil:
+		return UpdateTypeMessage
+	case u.EditedMessage != nil:
+		return UpdateTypeEditedMessage
+	case u.ChannelPost != nil:
+		return UpdateTypeChannelPost
+	case u.EditedChannelPost != nil:
+		return UpdateTypeEditedChannelPost
+	case u.InlineQuery != nil:
+		return UpdateTypeInlineQuery
+	case u.ChosenInlineResult != nil:
+		return UpdateTypeChosenInlineResult
+	case u.CallbackQuery != nil:
+		return UpdateTypeCallbackQuery
+	case u.ShippingQuery != nil:
+		return UpdateTypeShippingQuery
+	case u.PreCheckoutQuery != nil:
+		return UpdateTypePreCheckoutQuery
+	case u.Poll != nil:
+		return UpdateTypePoll
+	case u.PollAnswer != nil:
+		return UpdateTypePollAnswer
+	case u.MyChatMember != nil:
+		return UpdateTypeMyChatMember
+	case u.ChatMember != nil:
+		return UpdateTypeChatMember
+	case u.ChatJoinRequest != nil:
+		return UpdateTypeChatJoinRequest
+	}
+
+	return ""
+}
+
+// Returns message of update: new or edited message or channel post, or
+// message with the button of callback query. Returns nil for other updates,
+// and for callback queries from inline messages, which have only
+// CallbackQuery.InlineMessageID.
+func (u *Update) EffectiveMessage() *Message {
+	switch {
+	case u.Message != nil:
+		return u.Message
+	case u.EditedMessage != nil:
+		return u.EditedMessage
+	case u.ChannelPost != nil:
+		return u.ChannelPost
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.Message
+	}
+
+	return nil
+}
+
+// Returns chat, where update happened. Returns nil for updates, which are not
+// bound to a chat, e.x. inline queries, polls, and callback queries from
+// inline messages.
+func (u *Update) EffectiveChat() *Chat {
+	if msg := u.EffectiveMessage(); msg != nil {
+		return msg.Chat
+	}
+
+	switch {
+	case u.MyChatMember != nil:
+		return u.MyChatMember.Chat
+	case u.ChatMember != nil:
+		return u.ChatMember.Chat
+	case u.ChatJoinRequest != nil:
+		return u.ChatJoinRequest.Chat
+	}
+
+	return nil
+}
+
+// Returns user, who caused update, e.x. sender of message or user, who
+// pressed the button of callback query. Returns nil for channel posts, polls,
+// and messages sent on behalf of a chat, e.x. by anonymous group
+// administrators, as From of such messages is a placeholder user, use
+// EffectiveSenderChat instead.
+func (u *Update) EffectiveUser() *User {
+	switch {
+	case u.Message != nil:
+		return messageSender(u.Message)
+	case u.EditedMessage != nil:
+		return messageSender(u.EditedMessage)
+	case u.ChannelPost != nil:
+		return messageSender(u.ChannelPost)
+	case u.EditedChannelPost != nil:
+		return messageSender(u.EditedChannelPost)
+	case u.InlineQuery != nil:
+		return u.InlineQuery.From
+	case u.ChosenInlineResult != nil:
+		return u.ChosenInlineResult.From
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.From
+	case u.ShippingQuery != nil:
+		return u.ShippingQuery.From
+	case u.PreCheckoutQuery != nil:
+		return u.PreCheckoutQuery.From
+	case u.PollAnswer != nil:
+		return u.PollAnswer.User
+	case u.MyChatMember != nil:
+		return u.MyChatMember.From
+	case u.ChatMember != nil:
+		return u.ChatMember.From
+	case u.ChatJoinRequest != nil:
+		return u.ChatJoinRequest.From
+	}
+
+	return nil
+}
+
+// Returns chat, on behalf of which message of update was sent: the channel
+// for channel posts and for messages automatically forwarded to discussion
+// group, the group for messages of anonymous administrators, or the channel,
+// which user chose to send message on behalf of. Returns nil otherwise,
+// including callback queries, which are always sent by users.
+func (u *Update) EffectiveSenderChat() *Chat {
+	switch {
+	case u.Message != nil:
+		return u.Message.SenderChat
+	case u.EditedMessage != nil:
+		return u.EditedMessage.SenderChat
+	case u.ChannelPost != nil:
+		return u.ChannelPost.SenderChat
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost.SenderChat
+	}
+
+	return nil
+}
+
+// Returns sender of message, or nil, if message is sent on behalf of a chat
+func messageSender(msg *Message) *User {
+	if msg.SenderChat != nil {
+		return nil
+	}
+
+	return msg.From
+}
@@ -0,0 +1,262 @@
+package telegrambot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Source of updates, e.x. long polling or webhook. Allows to write code
+// receiving updates once, and run it with different sources.
+//
+//	var source telegrambot.UpdateSource
+//	if webhook {
+//		webhookSource := telegrambot.NewWebhookSource(nil)
+//		http.Handle("/webhook", webhookSource)
+//		source = webhookSource
+//	} else {
+//		source = telegrambot.NewPollingSource(api, nil)
+//	}
+//	err := source.Run(ctx, dispatcher.Receive)
+type UpdateSource interface {
+	// Passes updates and errors of receiving them to receiver, until ctx is
+	// done or source has no more updates. Blocks until the last update is
+	// handled, or until source stops waiting for it, e.x. PollingSource after
+	// ShutdownTimeout.
+	Run(ctx context.Context, receiver func(update *Update, err error)) error
+}
+
+type PollingSourceParams struct {
+	// Optional. Parameters of Poller.
+	PollerParams *PollerParams
+	// Optional. Maximum time to wait for updates being handled, when ctx of
+	// Run is done. Defaults to 10 seconds.
+	ShutdownTimeout time.Duration
+}
+
+// Receives updates with long polling, see Poller
+type PollingSource struct {
+	api             *API
+	pollerParams    *PollerParams
+	shutdownTimeout time.Duration
+}
+
+// Creates source, which receives updates with Poller. params can be nil.
+func NewPollingSource(api *API, params *PollingSourceParams) *PollingSource {
+	if params == nil {
+		params = &PollingSourceParams{}
+	}
+
+	shutdownTimeout := params.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 10 * time.Second
+	}
+
+	return &PollingSource{
+		api:             api,
+		pollerParams:    params.PollerParams,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+// Starts new Poller, and shuts it down, when ctx is done, waiting for updates
+// being handled up to ShutdownTimeout. Returns error, which stopped poller,
+// e.x. 401 Unauthorized, or InFlightUpdatesError with updates, which were
+// still being handled after timeout.
+func (s *PollingSource) Run(ctx context.Context, receiver func(update *Update, err error)) error {
+	poller := NewPoller(s.api, s.pollerParams)
+	poller.Start(receiver)
+
+	select {
+	case <-ctx.Done():
+	case <-poller.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	err := poller.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("PollingSource.Run: %w", err)
+	}
+
+	err = poller.Err()
+	if err != nil {
+		return fmt.Errorf("PollingSource.Run: %w", err)
+	}
+
+	return nil
+}
+
+// Receives updates sent by Telegram to webhook, see WebhookHandler. Can be
+// used as http.Handler and as fasthttp request handler. Requests are
+// rejected with status 503, while Run is not running, so Telegram repeats
+// them later.
+type WebhookSource struct {
+	handler *WebhookHandler
+
+	mu       sync.RWMutex
+	receiver func(update *Update, err error)
+	closing  bool
+	requests sync.WaitGroup
+}
+
+// Creates webhook source. params can be nil.
+func NewWebhookSource(params *WebhookHandlerParams) *WebhookSource {
+	s := &WebhookSource{}
+	s.handler = NewWebhookHandler(params, func(update *Update, err error) {
+		s.mu.RLock()
+		receiver := s.receiver
+		s.mu.RUnlock()
+
+		receiver(update, err)
+	})
+
+	return s
+}
+
+// Passes updates of webhook requests to receiver, until ctx is done. Then
+// waits for requests being handled.
+func (s *WebhookSource) Run(ctx context.Context, receiver func(update *Update, err error)) error {
+	s.mu.Lock()
+	if s.receiver != nil {
+		s.mu.Unlock()
+		return errors.New("WebhookSource.Run: source is already running")
+	}
+	s.receiver = receiver
+	s.closing = false
+	s.mu.Unlock()
+
+	<-ctx.Done()
+
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+
+	s.requests.Wait()
+
+	s.mu.Lock()
+	s.receiver = nil
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Reports whether request can be handled, and registers it, if so
+func (s *WebhookSource) acquire() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.receiver == nil || s.closing {
+		return false
+	}
+
+	s.requests.Add(1)
+
+	return true
+}
+
+func (s *WebhookSource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.acquire() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	defer s.requests.Done()
+
+	s.handler.ServeHTTP(w, r)
+}
+
+// Handles webhook request, can be used as fasthttp.RequestHandler
+func (s *WebhookSource) HandleFasthttp(ctx *fasthttp.RequestCtx) {
+	if !s.acquire() {
+		ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+		return
+	}
+	defer s.requests.Done()
+
+	s.handler.HandleFasthttp(ctx)
+}
+
+// Passes updates from channel, e.x. in tests
+//
+//	updates := make(chan *telegrambot.Update)
+//	go telegrambot.ChanSource(updates).Run(ctx, receiver)
+//	updates <- &telegrambot.Update{...}
+type ChanSource <-chan *Update
+
+// Passes updates from channel to receiver, until ctx is done or channel is
+// closed
+func (s ChanSource) Run(ctx context.Context, receiver func(update *Update, err error)) error {
+	for {
+		select {
+		case update, ok := <-s:
+			if !ok {
+				return nil
+			}
+			receiver(update, nil)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Replays updates from JSONL file, which contains an update per line in the
+// same format as webhook requests, e.x. recorded with Update.RawJSON
+type ReplaySource struct {
+	path string
+}
+
+// Creates source, which replays updates from file at path
+func NewReplaySource(path string) *ReplaySource {
+	return &ReplaySource{
+		path: path,
+	}
+}
+
+// Passes updates from file to receiver in order, until ctx is done or file
+// ends. Lines, which can't be parsed, are passed to receiver as errors.
+func (s *ReplaySource) Run(ctx context.Context, receiver func(update *Update, err error)) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("ReplaySource.Run: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, DefaultWebhookMaxBodySize)
+
+	line := 0
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		update, err := ParseWebhookUpdate(scanner.Bytes())
+		if err != nil {
+			receiver(nil, fmt.Errorf("ReplaySource: line %v: %w", line, err))
+			continue
+		}
+
+		receiver(update, nil)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf("ReplaySource.Run: %w", err)
+	}
+
+	return nil
+}
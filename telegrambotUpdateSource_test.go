@@ -0,0 +1,59 @@
+package telegrambot_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nickname76/telegrambot"
+	"github.com/nickname76/telegrambot/telegrambottest"
+)
+
+func TestPollingSourceShutdownTimeout(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	updates := addMessages(server, 1)
+
+	received := make(chan *telegrambot.Update, 1)
+	release := make(chan struct{})
+	defer close(release)
+
+	source := telegrambot.NewPollingSource(server.API(), &telegrambot.PollingSourceParams{
+		ShutdownTimeout: 50 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runErr := make(chan error)
+	go func() {
+		runErr <- source.Run(ctx, func(update *telegrambot.Update, err error) {
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			received <- update
+			<-release
+		})
+	}()
+
+	nextUpdate(t, received)
+	cancel()
+
+	var err error
+	select {
+	case err = <-runErr:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run is blocked by stuck handler")
+	}
+
+	var inFlightErr *telegrambot.InFlightUpdatesError
+	if !errors.As(err, &inFlightErr) {
+		t.Fatalf("Run returned %v, want InFlightUpdatesError", err)
+	}
+	if len(inFlightErr.Updates) != 1 || inFlightErr.Updates[0].UpdateID != updates[0].UpdateID {
+		t.Errorf("in-flight updates are %v, want update %v", inFlightErr.Updates, updates[0].UpdateID)
+	}
+}
@@ -0,0 +1,142 @@
+package telegrambot_test
+
+import (
+	"testing"
+
+	"github.com/nickname76/telegrambot"
+)
+
+func TestUpdateEffectiveFields(t *testing.T) {
+	user := &telegrambot.User{ID: 1, FirstName: "Alice"}
+	// Placeholder sender of messages sent on behalf of chats
+	groupAnonymousBot := &telegrambot.User{ID: 1087968824, IsBot: true, FirstName: "Group", Username: "GroupAnonymousBot"}
+	privateChat := &telegrambot.Chat{ID: 1, Type: telegrambot.ChatTypePrivate}
+	group := &telegrambot.Chat{ID: -1001, Type: telegrambot.ChatTypeSupergroup}
+	channel := &telegrambot.Chat{ID: -1002, Type: telegrambot.ChatTypeChannel}
+
+	message := &telegrambot.Message{MessageID: 1, From: user, Chat: privateChat}
+	anonymousAdminMessage := &telegrambot.Message{MessageID: 2, From: groupAnonymousBot, SenderChat: group, Chat: group}
+	channelPost := &telegrambot.Message{MessageID: 3, SenderChat: channel, Chat: channel}
+	chatMemberUpdated := &telegrambot.ChatMemberUpdated{Chat: group, From: user}
+
+	tests := []struct {
+		name       string
+		update     *telegrambot.Update
+		message    *telegrambot.Message
+		chat       *telegrambot.Chat
+		user       *telegrambot.User
+		senderChat *telegrambot.Chat
+	}{
+		{
+			name:    "message",
+			update:  &telegrambot.Update{Message: message},
+			message: message, chat: privateChat, user: user,
+		},
+		{
+			name:    "message of anonymous admin",
+			update:  &telegrambot.Update{Message: anonymousAdminMessage},
+			message: anonymousAdminMessage, chat: group, senderChat: group,
+		},
+		{
+			name:    "edited message",
+			update:  &telegrambot.Update{EditedMessage: message},
+			message: message, chat: privateChat, user: user,
+		},
+		{
+			name:    "edited message of anonymous admin",
+			update:  &telegrambot.Update{EditedMessage: anonymousAdminMessage},
+			message: anonymousAdminMessage, chat: group, senderChat: group,
+		},
+		{
+			name:    "channel post",
+			update:  &telegrambot.Update{ChannelPost: channelPost},
+			message: channelPost, chat: channel, senderChat: channel,
+		},
+		{
+			name:    "edited channel post",
+			update:  &telegrambot.Update{EditedChannelPost: channelPost},
+			message: channelPost, chat: channel, senderChat: channel,
+		},
+		{
+			name:   "inline query",
+			update: &telegrambot.Update{InlineQuery: &telegrambot.InlineQuery{ID: "1", From: user}},
+			user:   user,
+		},
+		{
+			name:   "chosen inline result",
+			update: &telegrambot.Update{ChosenInlineResult: &telegrambot.ChosenInlineResult{ResultID: "1", From: user}},
+			user:   user,
+		},
+		{
+			name:    "callback query",
+			update:  &telegrambot.Update{CallbackQuery: &telegrambot.CallbackQuery{ID: "1", From: user, Message: message}},
+			message: message, chat: privateChat, user: user,
+		},
+		{
+			// Button of message of anonymous admin is pressed by user
+			name:    "callback query of message of anonymous admin",
+			update:  &telegrambot.Update{CallbackQuery: &telegrambot.CallbackQuery{ID: "1", From: user, Message: anonymousAdminMessage}},
+			message: anonymousAdminMessage, chat: group, user: user,
+		},
+		{
+			name:   "callback query of inline message",
+			update: &telegrambot.Update{CallbackQuery: &telegrambot.CallbackQuery{ID: "1", From: user, InlineMessageID: "AAAA"}},
+			user:   user,
+		},
+		{
+			name:   "shipping query",
+			update: &telegrambot.Update{ShippingQuery: &telegrambot.ShippingQuery{ID: "1", From: user}},
+			user:   user,
+		},
+		{
+			name:   "pre-checkout query",
+			update: &telegrambot.Update{PreCheckoutQuery: &telegrambot.PreCheckoutQuery{ID: "1", From: user}},
+			user:   user,
+		},
+		{
+			name:   "poll",
+			update: &telegrambot.Update{Poll: &telegrambot.Poll{ID: "1", Question: "?"}},
+		},
+		{
+			name:   "poll answer",
+			update: &telegrambot.Update{PollAnswer: &telegrambot.PollAnswer{PollID: "1", User: user}},
+			user:   user,
+		},
+		{
+			name:   "my chat member",
+			update: &telegrambot.Update{MyChatMember: chatMemberUpdated},
+			chat:   group, user: user,
+		},
+		{
+			name:   "chat member",
+			update: &telegrambot.Update{ChatMember: chatMemberUpdated},
+			chat:   group, user: user,
+		},
+		{
+			name:   "chat join request",
+			update: &telegrambot.Update{ChatJoinRequest: &telegrambot.ChatJoinRequest{Chat: group, From: user}},
+			chat:   group, user: user,
+		},
+		{
+			name:   "unknown",
+			update: &telegrambot.Update{UpdateID: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if message := test.update.EffectiveMessage(); message != test.message {
+				t.Errorf("EffectiveMessage() = %+v, want %+v", message, test.message)
+			}
+			if chat := test.update.EffectiveChat(); chat != test.chat {
+				t.Errorf("EffectiveChat() = %+v, want %+v", chat, test.chat)
+			}
+			if user := test.update.EffectiveUser(); user != test.user {
+				t.Errorf("EffectiveUser() = %+v, want %+v", user, test.user)
+			}
+			if senderChat := test.update.EffectiveSenderChat(); senderChat != test.senderChat {
+				t.Errorf("EffectiveSenderChat() = %+v, want %+v", senderChat, test.senderChat)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package telegrambot
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Default maximum size of webhook request body
+const DefaultWebhookMaxBodySize = 1 << 20
+
+// Header with secret token, which is sent in every webhook request, if it was
+// specified in SetWebhookParams.SecretToken
+const WebhookSecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+type WebhookHandlerParams struct {
+	// Optional. Same as SetWebhookParams.SecretToken. Requests without it in
+	// X-Telegram-Bot-Api-Secret-Token header are rejected. Empty means, that
+	// header is not checked.
+	SecretToken string
+	// Optional. Maximum size of request body in bytes, larger requests are
+	// rejected. Defaults to DefaultWebhookMaxBodySize.
+	MaxBodySize int64
+}
+
+// Receives updates sent by Telegram to webhook, can be used as http.Handler
+// and as fasthttp request handler (see HandleFasthttp).
+//
+// Update is passed to receiver before responding, so Telegram does not send
+// the next update until receiver returns, same as with StartReceivingUpdates.
+// Errors of parsing request body are also passed to receiver. Telegram may
+// repeat requests, use UpdateDeduplicator to ignore repeated updates.
+//
+// Responds with status
+//
+//	200 - update is received
+//	400 - request body is not a valid update
+//	403 - secret token is invalid
+//	405 - request method is not POST
+//	413 - request body is too large
+type WebhookHandler struct {
+	secretToken string
+	maxBodySize int64
+	receiver    func(update *Update, err error)
+}
+
+// Creates webhook handler. params can be nil.
+//
+//	handler := telegrambot.NewWebhookHandler(&telegrambot.WebhookHandlerParams{
+//		SecretToken: secretToken,
+//	}, func(update *telegrambot.Update, err error) {
+//		...
+//	})
+//	http.Handle("/webhook", handler)
+func NewWebhookHandler(params *WebhookHandlerParams, receiver func(update *Update, err error)) *WebhookHandler {
+	if params == nil {
+		params = &WebhookHandlerParams{}
+	}
+
+	maxBodySize := params.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultWebhookMaxBodySize
+	}
+
+	return &WebhookHandler{
+		secretToken: params.SecretToken,
+		maxBodySize: maxBodySize,
+		receiver:    receiver,
+	}
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.validSecretToken(r.Header.Get(WebhookSecretTokenHeader)) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.receiver(nil, fmt.Errorf("WebhookHandler: request body exceeds %v bytes", h.maxBodySize))
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		h.receiver(nil, fmt.Errorf("WebhookHandler: %w", err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(h.handleBody(body))
+}
+
+// Handles webhook request, can be used as fasthttp.RequestHandler. Request
+// body size should be also limited with fasthttp.Server.MaxRequestBodySize,
+// otherwise it is read completely before checking.
+//
+//	fasthttp.ListenAndServe(":8443", handler.HandleFasthttp)
+func (h *WebhookHandler) HandleFasthttp(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsPost() {
+		ctx.Response.Header.Set("Allow", fasthttp.MethodPost)
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.validSecretToken(string(ctx.Request.Header.Peek(WebhookSecretTokenHeader))) {
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		return
+	}
+
+	if int64(ctx.Request.Header.ContentLength()) > h.maxBodySize || int64(len(ctx.PostBody())) > h.maxBodySize {
+		h.receiver(nil, fmt.Errorf("WebhookHandler: request body exceeds %v bytes", h.maxBodySize))
+		ctx.SetStatusCode(fasthttp.StatusRequestEntityTooLarge)
+		return
+	}
+
+	ctx.SetStatusCode(h.handleBody(ctx.PostBody()))
+}
+
+// Compares secret token in constant time, to not leak it by response timings
+func (h *WebhookHandler) validSecretToken(secretToken string) bool {
+	if h.secretToken == "" {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(secretToken), []byte(h.secretToken)) == 1
+}
+
+// Parses update and passes it to receiver, returns response status code
+func (h *WebhookHandler) handleBody(body []byte) int {
+	update, err := ParseWebhookUpdate(body)
+	if err != nil {
+		h.receiver(nil, fmt.Errorf("WebhookHandler: %w", err))
+		return http.StatusBadRequest
+	}
+
+	h.receiver(update, nil)
+
+	return http.StatusOK
+}
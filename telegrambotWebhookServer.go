@@ -0,0 +1,260 @@
+package telegrambot
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Ports, which Telegram supports for webhooks
+var WebhookPorts = []int{443, 80, 88, 8443}
+
+// Generates self-signed certificate for host, which is IP address or domain
+// name, and its private key, both PEM encoded. Certificate can be used for
+// webhook, by uploading it with SetWebhookParams.Certificate.
+//
+// https://core.telegram.org/bots/self-signed
+func GenerateSelfSignedCertificate(host string, validFor time.Duration) (certPEM []byte, keyPEM []byte, err error) {
+	if host == "" {
+		return nil, nil, errors.New("GenerateSelfSignedCertificate: host is empty")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GenerateSelfSignedCertificate: %w", err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("GenerateSelfSignedCertificate: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName: host,
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GenerateSelfSignedCertificate: %w", err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return certPEM, keyPEM, nil
+}
+
+type WebhookServerParams struct {
+	// Public IP address or domain name of the server, which Telegram sends
+	// updates to
+	Host string
+	// Optional. Public port of the server, one of WebhookPorts. Defaults to
+	// 8443.
+	Port int
+	// Optional. Address to listen on, e.x. when server is behind NAT. Defaults
+	// to ":Port".
+	ListenAddr string
+	// Optional. Path of webhook url. Defaults to "/".
+	Path string
+	// Optional. Self-signed certificate and its private key, PEM encoded. New
+	// certificate for Host, valid for a year, is generated, if not set.
+	CertificatePEM []byte
+	PrivateKeyPEM  []byte
+	// Optional. Secret token, which Telegram sends in every webhook request.
+	// Random one is generated, if not set.
+	SecretToken string
+	// Optional. Same as SetWebhookParams.MaxConnections
+	MaxConnections int
+	// Optional. Same as SetWebhookParams.AllowedUpdates
+	AllowedUpdates []UpdateType
+	// Optional. Same as SetWebhookParams.DropPendingUpdates
+	DropPendingUpdates bool
+	// Optional. Same as WebhookHandlerParams.MaxBodySize
+	MaxBodySize int64
+}
+
+// HTTPS server, receiving updates with webhook. See StartWebhookServer.
+type WebhookServer struct {
+	api      *API
+	server   *http.Server
+	listener net.Listener
+	url      string
+	certPEM  []byte
+}
+
+// Starts HTTPS server with self-signed certificate and sets webhook to it, so
+// that updates are passed to receiver. Call Shutdown to delete webhook and
+// stop server.
+//
+//	server, err := telegrambot.StartWebhookServer(api, &telegrambot.WebhookServerParams{
+//		Host: "203.0.113.10",
+//	}, receiver)
+//	...
+//	err = server.Shutdown(ctx)
+func StartWebhookServer(api *API, params *WebhookServerParams, receiver func(update *Update, err error)) (*WebhookServer, error) {
+	if params == nil {
+		params = &WebhookServerParams{}
+	}
+
+	if params.Host == "" {
+		return nil, errors.New("StartWebhookServer: Host is not set")
+	}
+
+	port := params.Port
+	if port == 0 {
+		port = 8443
+	}
+
+	supportedPort := false
+	for _, webhookPort := range WebhookPorts {
+		supportedPort = supportedPort || port == webhookPort
+	}
+	if !supportedPort {
+		return nil, fmt.Errorf("StartWebhookServer: port %v is not supported for webhooks, use one of %v", port, WebhookPorts)
+	}
+
+	listenAddr := params.ListenAddr
+	if listenAddr == "" {
+		listenAddr = ":" + strconv.Itoa(port)
+	}
+
+	path := params.Path
+	if path == "" {
+		path = "/"
+	}
+
+	certPEM, keyPEM := params.CertificatePEM, params.PrivateKeyPEM
+	if certPEM == nil {
+		var err error
+		certPEM, keyPEM, err = GenerateSelfSignedCertificate(params.Host, 365*24*time.Hour)
+		if err != nil {
+			return nil, fmt.Errorf("StartWebhookServer: %w", err)
+		}
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("StartWebhookServer: %w", err)
+	}
+
+	secretToken := params.SecretToken
+	if secretToken == "" {
+		secretTokenBytes := make([]byte, 32)
+		_, err = rand.Read(secretTokenBytes)
+		if err != nil {
+			return nil, fmt.Errorf("StartWebhookServer: %w", err)
+		}
+		secretToken = hex.EncodeToString(secretTokenBytes)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, NewWebhookHandler(&WebhookHandlerParams{
+		SecretToken: secretToken,
+		MaxBodySize: params.MaxBodySize,
+	}, receiver))
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("StartWebhookServer: %w", err)
+	}
+
+	s := &WebhookServer{
+		api: api,
+		server: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 30 * time.Second,
+		},
+		listener: tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}),
+		url:     "https://" + net.JoinHostPort(params.Host, strconv.Itoa(port)) + path,
+		certPEM: certPEM,
+	}
+
+	go func() {
+		err := s.server.Serve(s.listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			receiver(nil, fmt.Errorf("WebhookServer: %w", err))
+		}
+	}()
+
+	err = api.SetWebhook(&SetWebhookParams{
+		URL: s.url,
+		Certificate: &FileReader{
+			Name:   "certificate.pem",
+			Reader: bytes.NewReader(certPEM),
+		},
+		MaxConnections:     params.MaxConnections,
+		AllowedUpdates:     params.AllowedUpdates,
+		DropPendingUpdates: params.DropPendingUpdates,
+		SecretToken:        secretToken,
+	})
+	if err != nil {
+		s.server.Close()
+		return nil, fmt.Errorf("StartWebhookServer: %w", err)
+	}
+
+	return s, nil
+}
+
+// Returns url of webhook
+func (s *WebhookServer) URL() string {
+	return s.url
+}
+
+// Returns address server listens on
+func (s *WebhookServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Returns PEM encoded certificate of server
+func (s *WebhookServer) CertificatePEM() []byte {
+	return s.certPEM
+}
+
+// Deletes webhook, so that Telegram stops sending updates, and gracefully
+// stops server, waiting for updates being received. Pending updates are kept
+// by Telegram, so they can be received later.
+func (s *WebhookServer) Shutdown(ctx context.Context) error {
+	deleteErr := s.api.WithContext(ctx).DeleteWebhook(&DeleteWebhookParams{})
+
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("WebhookServer.Shutdown: %w", err)
+	}
+
+	if deleteErr != nil {
+		return fmt.Errorf("WebhookServer.Shutdown: %w", deleteErr)
+	}
+
+	return nil
+}
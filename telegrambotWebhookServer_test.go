@@ -0,0 +1,102 @@
+package telegrambot_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nickname76/telegrambot"
+	"github.com/nickname76/telegrambot/telegrambottest"
+)
+
+func TestStartWebhookServerWithoutHost(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	receiver := func(update *telegrambot.Update, err error) {}
+
+	for _, params := range []*telegrambot.WebhookServerParams{nil, {Port: 8443}} {
+		_, err := telegrambot.StartWebhookServer(server.API(), params, receiver)
+		if err == nil {
+			t.Errorf("StartWebhookServer(%+v) returned nil error", params)
+		}
+	}
+
+	if len(server.RequestsOf("setWebhook")) != 0 {
+		t.Error("webhook is set without host")
+	}
+
+	_, _, err := telegrambot.GenerateSelfSignedCertificate("", time.Hour)
+	if err == nil {
+		t.Error("certificate is generated for empty host")
+	}
+}
+
+func TestStartWebhookServer(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	received := make(chan *telegrambot.Update, 1)
+
+	webhookServer, err := telegrambot.StartWebhookServer(server.API(), &telegrambot.WebhookServerParams{
+		Host:        "127.0.0.1",
+		ListenAddr:  "127.0.0.1:0",
+		Path:        "/webhook",
+		SecretToken: "secret",
+	}, func(update *telegrambot.Update, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		received <- update
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if url := server.WebhookURL(); url != "https://127.0.0.1:8443/webhook" || url != webhookServer.URL() {
+		t.Errorf("webhook url is %q, server url is %q", url, webhookServer.URL())
+	}
+
+	certs := x509.NewCertPool()
+	certs.AppendCertsFromPEM(webhookServer.CertificatePEM())
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: certs},
+		},
+	}
+
+	// Telegram connects to public port, test connects to listening address
+	req, err := http.NewRequest("POST", "https://"+webhookServer.Addr().String()+"/webhook", bytes.NewReader([]byte(`{"update_id":1}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "secret")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("webhook responded with %v", resp.Status)
+	}
+	if update := nextUpdate(t, received); update.UpdateID != 1 {
+		t.Errorf("received update %v, want 1", update.UpdateID)
+	}
+
+	err = webhookServer.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if url := server.WebhookURL(); url != "" {
+		t.Errorf("webhook %q is not deleted", url)
+	}
+}
@@ -0,0 +1,103 @@
+package telegrambot_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nickname76/telegrambot"
+	"github.com/valyala/fasthttp"
+)
+
+var webhookHandlerTests = []struct {
+	name        string
+	method      string
+	secretToken string
+	body        string
+	status      int
+	update      bool
+	err         bool
+}{
+	{"update", "POST", "secret", `{"update_id":1,"message":{"message_id":1}}`, 200, true, false},
+	{"invalid body", "POST", "secret", `{"update_id":`, 400, false, true},
+	{"missing secret token", "POST", "", `{"update_id":1}`, 403, false, false},
+	{"wrong secret token", "POST", "wrong", `{"update_id":1}`, 403, false, false},
+	{"wrong method", "GET", "secret", "", 405, false, false},
+	{"too large body", "POST", "secret", `{"update_id":1,"message":{"text":"` + strings.Repeat("x", 100) + `"}}`, 413, false, true},
+}
+
+// Returns webhook handler and counters of updates and errors passed to its
+// receiver
+func newTestWebhookHandler() (handler *telegrambot.WebhookHandler, updates *int, errs *int) {
+	updates, errs = new(int), new(int)
+
+	handler = telegrambot.NewWebhookHandler(&telegrambot.WebhookHandlerParams{
+		SecretToken: "secret",
+		MaxBodySize: 64,
+	}, func(update *telegrambot.Update, err error) {
+		if err != nil {
+			*errs++
+			return
+		}
+
+		*updates++
+	})
+
+	return handler, updates, errs
+}
+
+func TestWebhookHandlerServeHTTP(t *testing.T) {
+	for _, test := range webhookHandlerTests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, updates, errs := newTestWebhookHandler()
+
+			req := httptest.NewRequest(test.method, "/webhook", bytes.NewReader([]byte(test.body)))
+			if test.secretToken != "" {
+				req.Header.Set(telegrambot.WebhookSecretTokenHeader, test.secretToken)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != test.status {
+				t.Errorf("status is %v, want %v", w.Code, test.status)
+			}
+			if (*updates == 1) != test.update || (*errs == 1) != test.err {
+				t.Errorf("receiver got %v updates and %v errors", *updates, *errs)
+			}
+			if test.status == http.StatusMethodNotAllowed && w.Header().Get("Allow") != "POST" {
+				t.Errorf("Allow header is %q", w.Header().Get("Allow"))
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerHandleFasthttp(t *testing.T) {
+	for _, test := range webhookHandlerTests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, updates, errs := newTestWebhookHandler()
+
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(test.method)
+			ctx.Request.SetRequestURI("/webhook")
+			if test.secretToken != "" {
+				ctx.Request.Header.Set(telegrambot.WebhookSecretTokenHeader, test.secretToken)
+			}
+			ctx.Request.SetBodyString(test.body)
+
+			handler.HandleFasthttp(ctx)
+
+			if status := ctx.Response.StatusCode(); status != test.status {
+				t.Errorf("status is %v, want %v", status, test.status)
+			}
+			if (*updates == 1) != test.update || (*errs == 1) != test.err {
+				t.Errorf("receiver got %v updates and %v errors", *updates, *errs)
+			}
+			if test.status == http.StatusMethodNotAllowed && string(ctx.Response.Header.Peek("Allow")) != "POST" {
+				t.Errorf("Allow header is %q", ctx.Response.Header.Peek("Allow"))
+			}
+		})
+	}
+}
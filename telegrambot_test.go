@@ -0,0 +1,232 @@
+package telegrambot_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickname76/telegrambot"
+	"github.com/nickname76/telegrambot/telegrambottest"
+)
+
+func TestCustomHttpDoRequestIsUsed(t *testing.T) {
+	requests := 0
+	api := &telegrambot.API{
+		Token:       testToken,
+		EndpointURL: telegrambot.DefaultAPIEndpointURL,
+		HttpDoRequest: func(method string, url string, headers map[string]string, body []byte) ([]byte, error) {
+			requests++
+			return []byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Bot"}}`), nil
+		},
+	}
+
+	_, err := api.GetMe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Fatalf("custom HttpDoRequest was called %v times", requests)
+	}
+}
+
+func TestDefaultHttpDoRequestRespectsContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// Same fields as set by NewAPI
+	api := &telegrambot.API{
+		Token:         testToken,
+		EndpointURL:   server.URL + "/bot",
+		HttpDoRequest: telegrambot.DefaultHttpDoRequest,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := api.WithContext(ctx).GetMe()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("request was not aborted, took %v", elapsed)
+	}
+}
+
+func TestUploadUsesHttpDoRequestWithoutStreaming(t *testing.T) {
+	var contentType string
+	api := &telegrambot.API{
+		Token:       testToken,
+		EndpointURL: telegrambot.DefaultAPIEndpointURL,
+		HttpDoRequestContext: func(ctx context.Context, method string, url string, headers map[string]string, body []byte) ([]byte, error) {
+			contentType = headers["Content-Type"]
+			return []byte(`{"ok":true,"result":{"message_id":1,"date":1,"chat":{"id":1,"type":"private"}}}`), nil
+		},
+	}
+
+	_, err := api.SendDocument(&telegrambot.SendDocumentParams{
+		ChatID: telegrambot.ChatID(1),
+		Document: &telegrambot.FileReader{
+			Name:   "file.txt",
+			Reader: strings.NewReader("content"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(contentType, "multipart/form-data") {
+		t.Fatalf("upload was not passed to HttpDoRequestContext, content type %q", contentType)
+	}
+}
+
+// Returns server with group, which was migrated to supergroup
+func newMigratedGroupServer(t *testing.T) (server *telegrambottest.Server, group *telegrambot.Chat, supergroup *telegrambot.Chat) {
+	t.Helper()
+
+	server = telegrambottest.NewServer()
+
+	group = server.NewChat(telegrambot.ChatTypeGroup, "Group")
+	supergroup, err := server.MigrateChat(group.ID)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+
+	return server, group, supergroup
+}
+
+func TestChatMigratedError(t *testing.T) {
+	server, group, supergroup := newMigratedGroupServer(t)
+	defer server.Close()
+
+	var migratedFrom, migratedTo telegrambot.ChatID
+
+	api := server.API()
+	api.OnChatMigrated = func(oldChatID telegrambot.ChatID, newChatID telegrambot.ChatID) {
+		migratedFrom, migratedTo = oldChatID, newChatID
+	}
+
+	_, err := api.SendMessage(&telegrambot.SendMessageParams{
+		ChatID: group.ID,
+		Text:   "Hello",
+	})
+
+	var chatMigratedErr *telegrambot.ChatMigratedError
+	if !errors.As(err, &chatMigratedErr) {
+		t.Fatalf("SendMessage returned %v, want ChatMigratedError", err)
+	}
+	if chatMigratedErr.OldChatID != group.ID || chatMigratedErr.NewChatID != supergroup.ID {
+		t.Errorf("chat is migrated from %v to %v, want from %v to %v", chatMigratedErr.OldChatID, chatMigratedErr.NewChatID, group.ID, supergroup.ID)
+	}
+	if migratedFrom != group.ID || migratedTo != supergroup.ID {
+		t.Errorf("OnChatMigrated is called with %v and %v, want %v and %v", migratedFrom, migratedTo, group.ID, supergroup.ID)
+	}
+	if requests := server.RequestsOf("sendMessage"); len(requests) != 1 {
+		t.Errorf("request is sent %v times without ResendOnChatMigration", len(requests))
+	}
+}
+
+// Reader, which does not implement io.Seeker
+type onlyReader struct {
+	io.Reader
+}
+
+func TestResendOnChatMigrationWithUpload(t *testing.T) {
+	for _, streamed := range []bool{false, true} {
+		server, group, supergroup := newMigratedGroupServer(t)
+		defer server.Close()
+
+		api := server.API()
+		api.ResendOnChatMigration = true
+		if !streamed {
+			api.HttpDoStreamRequest = nil
+		}
+
+		params := &telegrambot.SendDocumentParams{
+			ChatID: group.ID,
+			Document: &telegrambot.FileReader{
+				Name:   "file.txt",
+				Reader: strings.NewReader("hello world"),
+			},
+		}
+
+		msg, err := api.SendDocument(params)
+		if err != nil {
+			t.Fatalf("streamed %v: %v", streamed, err)
+		}
+		if msg.Chat.ID != supergroup.ID || params.ChatID != supergroup.ID {
+			t.Errorf("streamed %v: document is sent to %v, want %v", streamed, msg.Chat.ID, supergroup.ID)
+		}
+
+		requests := server.RequestsOf("sendDocument")
+		if len(requests) != 2 {
+			t.Fatalf("streamed %v: %v requests are sent, want 2", streamed, len(requests))
+		}
+		for _, file := range requests[1].Files {
+			if string(file.Data) != "hello world" {
+				t.Errorf("streamed %v: resent file is %q", streamed, file.Data)
+			}
+		}
+
+		// File, which can't be read again, is not resent
+		_, err = api.SendDocument(&telegrambot.SendDocumentParams{
+			ChatID: group.ID,
+			Document: &telegrambot.FileReader{
+				Name:   "file.txt",
+				Reader: onlyReader{strings.NewReader("hello world")},
+			},
+		})
+
+		var chatMigratedErr *telegrambot.ChatMigratedError
+		if !errors.As(err, &chatMigratedErr) {
+			t.Errorf("streamed %v: SendDocument returned %v, want ChatMigratedError", streamed, err)
+		}
+		if requests := server.RequestsOf("sendDocument"); len(requests) != 3 {
+			t.Errorf("streamed %v: not seekable file is resent", streamed)
+		}
+	}
+}
+
+func TestCallResendOnChatMigrationWithUpload(t *testing.T) {
+	server, group, supergroup := newMigratedGroupServer(t)
+	defer server.Close()
+
+	api := server.API()
+	api.ResendOnChatMigration = true
+
+	document := &telegrambot.FileReader{
+		Name:   "file.txt",
+		Reader: strings.NewReader("hello world"),
+	}
+
+	msg := &telegrambot.Message{}
+	err := api.Call("sendDocument", map[string]any{
+		"chat_id":  group.ID,
+		"document": document,
+	}, []telegrambot.InputFile{document}, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Chat.ID != supergroup.ID {
+		t.Errorf("document is sent to %v, want %v", msg.Chat.ID, supergroup.ID)
+	}
+
+	requests := server.RequestsOf("sendDocument")
+	if len(requests) != 2 {
+		t.Fatalf("%v requests are sent, want 2", len(requests))
+	}
+	for _, file := range requests[1].Files {
+		if string(file.Data) != "hello world" {
+			t.Errorf("resent file is %q", file.Data)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package telegrambottest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"regexp"
+	"sync"
+)
+
+// Recorded pair of request to Bot API and its response
+type Interaction struct {
+	// HTTP method
+	Method string `json:"method"`
+	// Request URL with bot token replaced by <TOKEN>
+	URL string `json:"url"`
+	// Normalized request body. Multipart bodies are converted to JSON object
+	// of fields, with attached files referred by SHA-256 of their contents.
+	Request json.RawMessage `json:"request,omitempty"`
+	// Response body, if it is valid JSON
+	Response json.RawMessage `json:"response,omitempty"`
+	// Response body, if it is not valid JSON
+	ResponseText string `json:"response_text,omitempty"`
+	// Error of performing request
+	Error string `json:"error,omitempty"`
+}
+
+// File with recorded interactions
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+var tokenInURLRegexp = regexp.MustCompile(`/bot[0-9]+:[A-Za-z0-9_-]+/`)
+
+// Replaces bot token in url with <TOKEN>
+func RedactURL(url string) string {
+	return tokenInURLRegexp.ReplaceAllString(url, "/bot<TOKEN>/")
+}
+
+// Records requests performed by next function to cassette file. File is
+// rewritten after each request.
+//
+//	rec := telegrambottest.NewRecorder("testdata/session.json", telegrambot.DefaultHttpDoRequestContext)
+//	api := &telegrambot.API{
+//		Token:                token,
+//		EndpointURL:          telegrambot.DefaultAPIEndpointURL,
+//		HttpDoRequestContext: rec.DoRequestContext,
+//	}
+type Recorder struct {
+	path string
+	next func(ctx context.Context, method string, url string, headers map[string]string, body []byte) (respBody []byte, err error)
+
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// Creates Recorder writing to file at path
+func NewRecorder(path string, next func(ctx context.Context, method string, url string, headers map[string]string, body []byte) (respBody []byte, err error)) *Recorder {
+	return &Recorder{
+		path:     path,
+		next:     next,
+		cassette: &Cassette{Interactions: []*Interaction{}},
+	}
+}
+
+// Performs and records request, can be used as API.HttpDoRequest
+func (r *Recorder) DoRequest(method string, url string, headers map[string]string, body []byte) (respBody []byte, err error) {
+	return r.DoRequestContext(context.Background(), method, url, headers, body)
+}
+
+// Performs and records request, can be used as API.HttpDoRequestContext
+func (r *Recorder) DoRequestContext(ctx context.Context, method string, url string, headers map[string]string, body []byte) (respBody []byte, err error) {
+	normalizedBody, err := normalizeRequestBody(headers, body)
+	if err != nil {
+		return nil, fmt.Errorf("Recorder.DoRequestContext: %w", err)
+	}
+
+	respBody, doErr := r.next(ctx, method, url, headers, body)
+
+	interaction := &Interaction{
+		Method:  method,
+		URL:     RedactURL(url),
+		Request: normalizedBody,
+	}
+
+	switch {
+	case doErr != nil:
+		interaction.Error = RedactURL(doErr.Error())
+	case json.Valid(respBody):
+		interaction.Response = append(json.RawMessage(nil), respBody...)
+	default:
+		interaction.ResponseText = string(respBody)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	err = writeCassette(r.path, r.cassette)
+	r.mu.Unlock()
+
+	if doErr != nil {
+		return nil, doErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Recorder.DoRequestContext: %w", err)
+	}
+
+	return respBody, nil
+}
+
+// Replays requests recorded with Recorder. Each recorded interaction is
+// replayed once, request is matched with the first unused interaction having
+// the same HTTP method, URL and normalized body.
+//
+//	rep, err := telegrambottest.NewReplayer("testdata/session.json")
+//	...
+//	api := &telegrambot.API{
+//		Token:                "123456:ANY",
+//		EndpointURL:          telegrambot.DefaultAPIEndpointURL,
+//		HttpDoRequestContext: rep.DoRequestContext,
+//	}
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// Creates Replayer reading cassette file at path
+func NewReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("NewReplayer: %w", err)
+	}
+
+	cassette := &Cassette{}
+	err = json.Unmarshal(data, cassette)
+	if err != nil {
+		return nil, fmt.Errorf("NewReplayer: %w", err)
+	}
+
+	// Cassette file is indented, so bodies must be compacted to be compared
+	for _, interaction := range cassette.Interactions {
+		if interaction.Request != nil {
+			buf := &bytes.Buffer{}
+			err = json.Compact(buf, interaction.Request)
+			if err != nil {
+				return nil, fmt.Errorf("NewReplayer: %w", err)
+			}
+			interaction.Request = buf.Bytes()
+		}
+	}
+
+	return &Replayer{
+		interactions: cassette.Interactions,
+		used:         make([]bool, len(cassette.Interactions)),
+	}, nil
+}
+
+// Replays request, can be used as API.HttpDoRequest
+func (r *Replayer) DoRequest(method string, url string, headers map[string]string, body []byte) (respBody []byte, err error) {
+	return r.DoRequestContext(context.Background(), method, url, headers, body)
+}
+
+// Replays request, can be used as API.HttpDoRequestContext
+func (r *Replayer) DoRequestContext(ctx context.Context, method string, url string, headers map[string]string, body []byte) (respBody []byte, err error) {
+	normalizedBody, err := normalizeRequestBody(headers, body)
+	if err != nil {
+		return nil, fmt.Errorf("Replayer.DoRequestContext: %w", err)
+	}
+
+	redactedURL := RedactURL(url)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Method != method || interaction.URL != redactedURL || !bytes.Equal(interaction.Request, normalizedBody) {
+			continue
+		}
+
+		r.used[i] = true
+
+		switch {
+		case interaction.Error != "":
+			return nil, errors.New(interaction.Error)
+		case interaction.Response != nil:
+			return interaction.Response, nil
+		default:
+			return []byte(interaction.ResponseText), nil
+		}
+	}
+
+	return nil, fmt.Errorf("Replayer.DoRequestContext: no recorded interaction for %v %v %s", method, redactedURL, normalizedBody)
+}
+
+// Returns recorded interactions, which were not replayed yet
+func (r *Replayer) Unused() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unused := []*Interaction{}
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+
+	return unused
+}
+
+func writeCassette(path string, cassette *Cassette) error {
+	buf := &bytes.Buffer{}
+
+	// Redacted token is written as <TOKEN>, not as escaped HTML
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+
+	err := enc.Encode(cassette)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+var attachRegexp = regexp.MustCompile(`attach://[A-Za-z0-9_-]+`)
+
+// Returns request body as JSON with sorted keys. Random multipart field names
+// of attached files are replaced with hashes of their contents.
+func normalizeRequestBody(headers map[string]string, body []byte) (json.RawMessage, error) {
+	mediaType, mediaParams, _ := mime.ParseMediaType(headers["Content-Type"])
+	if mediaType != "multipart/form-data" {
+		if len(body) == 0 {
+			return nil, nil
+		}
+
+		var v any
+		err := json.Unmarshal(body, &v)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(v)
+	}
+
+	fields := map[string]string{}
+	fileHashes := map[string]string{}
+
+	mr := multipart.NewReader(bytes.NewReader(body), mediaParams["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FileName() != "" {
+			hash := sha256.Sum256(data)
+			fileHashes[part.FormName()] = "attach://sha256:" + hex.EncodeToString(hash[:])
+		} else {
+			fields[part.FormName()] = string(data)
+		}
+	}
+
+	normalizedFields := map[string]string{}
+	for key, value := range fields {
+		normalizedFields[key] = attachRegexp.ReplaceAllStringFunc(value, func(attach string) string {
+			if hash, ok := fileHashes[attach[len("attach://"):]]; ok {
+				return hash
+			}
+
+			return attach
+		})
+	}
+
+	return json.Marshal(normalizedFields)
+}
@@ -0,0 +1,146 @@
+package telegrambottest_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nickname76/telegrambot"
+	"github.com/nickname76/telegrambot/telegrambottest"
+)
+
+// Performs the same requests while recording and replaying
+func performCassetteRequests(t *testing.T, api *telegrambot.API, chatID telegrambot.ChatID) {
+	t.Helper()
+
+	_, err := api.GetMe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = api.SendMessage(&telegrambot.SendMessageParams{
+		ChatID: chatID,
+		Text:   "Hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each FileReader gets random attach name
+	_, err = api.SendDocument(&telegrambot.SendDocumentParams{
+		ChatID: chatID,
+		Document: &telegrambot.FileReader{
+			Name:   "report.txt",
+			Reader: strings.NewReader("report"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCassetteRoundTrip(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	user := server.NewUser("Alice")
+	chat := server.PrivateChat(user)
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	recorder := telegrambottest.NewRecorder(path, telegrambot.DefaultHttpDoRequestContext)
+
+	api := server.API()
+	api.HttpDoRequestContext = recorder.DoRequestContext
+	api.HttpDoStreamRequest = nil
+
+	performCassetteRequests(t, api, chat.ID)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(server.Token)) {
+		t.Fatalf("cassette contains bot token:\n%s", data)
+	}
+	if !bytes.Contains(data, []byte("/bot<TOKEN>/getMe")) {
+		t.Errorf("cassette doesn't contain redacted url:\n%s", data)
+	}
+	if !bytes.Contains(data, []byte("attach://sha256:")) {
+		t.Errorf("cassette doesn't contain hash of uploaded file:\n%s", data)
+	}
+
+	replayer, err := telegrambottest.NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replayed bot has other token
+	api = &telegrambot.API{
+		Token:                "654321:OTHER-token",
+		EndpointURL:          server.EndpointURL(),
+		HttpDoRequestContext: replayer.DoRequestContext,
+	}
+
+	performCassetteRequests(t, api, chat.ID)
+
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("%v interactions are not replayed", len(unused))
+	}
+
+	// Server is not requested by replayer
+	if requests := server.RequestsOf("sendMessage"); len(requests) != 1 {
+		t.Errorf("server received %v sendMessage requests, want 1", len(requests))
+	}
+}
+
+func TestReplayerMatchesNormalizedBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	err := os.WriteFile(path, []byte(`{
+		"interactions": [
+			{
+				"method": "POST",
+				"url": "https://api.telegram.org/bot<TOKEN>/sendMessage",
+				"request": {"chat_id": 1, "text": "Hello"},
+				"response": {"ok": true, "result": {"message_id": 1}}
+			}
+		]
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := telegrambottest.NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	url := "https://api.telegram.org/bot123456:TOKEN/sendMessage"
+
+	// Other text is not recorded
+	_, err = replayer.DoRequestContext(context.Background(), "POST", url, headers, []byte(`{"chat_id":1,"text":"Bye"}`))
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Fatalf("DoRequestContext returned %v, want no recorded interaction error", err)
+	}
+	if strings.Contains(err.Error(), "123456:TOKEN") {
+		t.Errorf("error contains bot token: %v", err)
+	}
+
+	// Order of keys and whitespace don't matter
+	respBody, err := replayer.DoRequestContext(context.Background(), "POST", url, headers, []byte(`{ "text": "Hello", "chat_id": 1 }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(respBody), `"message_id"`) {
+		t.Errorf("replayed response is %s", respBody)
+	}
+
+	// Each interaction is replayed once
+	_, err = replayer.DoRequestContext(context.Background(), "POST", url, headers, []byte(`{"chat_id":1,"text":"Hello"}`))
+	if err == nil {
+		t.Error("interaction is replayed twice")
+	}
+}
@@ -0,0 +1,734 @@
+package telegrambottest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nickname76/telegrambot"
+)
+
+// Handles request with mu locked. Returns result or error response.
+type methodHandler func(s *Server, req *Request) (any, *telegrambot.Response)
+
+// Handlers by lowercase method names, as Bot API method names are case
+// insensitive
+var methodHandlers = map[string]methodHandler{}
+
+func init() {
+	for method, handler := range map[string]methodHandler{
+		"getMe":                  (*Server).getMe,
+		"logOut":                 (*Server).returnTrue,
+		"close":                  (*Server).returnTrue,
+		"getUpdates":             (*Server).getUpdates,
+		"setWebhook":             (*Server).setWebhook,
+		"deleteWebhook":          (*Server).deleteWebhook,
+		"getWebhookInfo":         (*Server).getWebhookInfo,
+		"sendMessage":            (*Server).sendMessage,
+		"forwardMessage":         (*Server).forwardMessage,
+		"copyMessage":            (*Server).copyMessage,
+		"sendPhoto":              (*Server).sendMedia,
+		"sendAudio":              (*Server).sendMedia,
+		"sendDocument":           (*Server).sendMedia,
+		"sendVideo":              (*Server).sendMedia,
+		"sendAnimation":          (*Server).sendMedia,
+		"sendVoice":              (*Server).sendMedia,
+		"sendVideoNote":          (*Server).sendMedia,
+		"sendSticker":            (*Server).sendMedia,
+		"sendChatAction":         (*Server).sendChatAction,
+		"editMessageText":        (*Server).editMessageText,
+		"editMessageCaption":     (*Server).editMessageCaption,
+		"editMessageReplyMarkup": (*Server).editMessageReplyMarkup,
+		"deleteMessage":          (*Server).deleteMessage,
+		"answerCallbackQuery":    (*Server).answerCallbackQuery,
+		"answerInlineQuery":      (*Server).returnTrue,
+		"setMyCommands":          (*Server).returnTrue,
+		"deleteMyCommands":       (*Server).returnTrue,
+		"getChat":                (*Server).getChat,
+		"getFile":                (*Server).getFile,
+	} {
+		methodHandlers[strings.ToLower(method)] = handler
+	}
+}
+
+func errorResponse(errorCode int, description string) *telegrambot.Response {
+	return &telegrambot.Response{
+		ErrorCode:   errorCode,
+		Description: description,
+	}
+}
+
+var (
+	errChatNotFound          = errorResponse(400, "Bad Request: chat not found")
+	errMessageTextEmpty      = errorResponse(400, "Bad Request: message text is empty")
+	errMessageTooLong        = errorResponse(400, "Bad Request: message is too long")
+	errMessageToEditNotFound = errorResponse(400, "Bad Request: message to edit not found")
+	errMessageToDelNotFound  = errorResponse(400, "Bad Request: message to delete not found")
+	errMessageToFwdNotFound  = errorResponse(400, "Bad Request: message to forward not found")
+	errMessageToCopyNotFound = errorResponse(400, "Bad Request: message to copy not found")
+	errReplyMessageNotFound  = errorResponse(400, "Bad Request: replied message not found")
+	errMessageNotModified    = errorResponse(400, "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message")
+	errQueryTooOld           = errorResponse(400, "Bad Request: query is too old and response timeout expired or query ID is invalid")
+	errWrongFileID           = errorResponse(400, "Bad Request: wrong file identifier/HTTP URL specified")
+	errFileEmpty             = errorResponse(400, "Bad Request: file must be non-empty")
+	errWebhookActive         = errorResponse(409, "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first")
+)
+
+func (s *Server) returnTrue(req *Request) (any, *telegrambot.Response) {
+	return true, nil
+}
+
+func (s *Server) getMe(req *Request) (any, *telegrambot.Response) {
+	return s.bot, nil
+}
+
+// Blocks long polling request until there are updates, timeout exceeds, or
+// request is canceled
+func (s *Server) waitForUpdates(r *http.Request, req *Request) {
+	timeout := time.Second * time.Duration(req.Params.Int64("timeout"))
+	if timeout <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	offset := telegrambot.UpdateID(req.Params.Int64("offset"))
+
+	for {
+		s.mu.Lock()
+		hasUpdates := false
+		for _, update := range s.updates {
+			if update.UpdateID >= offset {
+				hasUpdates = true
+				break
+			}
+		}
+		webhookSet := s.webhookURL != ""
+		changed := s.changed
+		s.mu.Unlock()
+
+		if hasUpdates || webhookSet {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) getUpdates(req *Request) (any, *telegrambot.Response) {
+	if s.webhookURL != "" {
+		return nil, errWebhookActive
+	}
+
+	offset := telegrambot.UpdateID(req.Params.Int64("offset"))
+
+	limit := int(req.Params.Int64("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	// Updates with identifiers lower than offset are confirmed
+	if offset > 0 {
+		pending := s.updates[:0]
+		for _, update := range s.updates {
+			if update.UpdateID >= offset {
+				pending = append(pending, update)
+			}
+		}
+		s.updates = pending
+	}
+
+	updates := s.updates
+	if len(updates) > limit {
+		updates = updates[:limit]
+	}
+
+	return append([]*telegrambot.Update{}, updates...), nil
+}
+
+func (s *Server) setWebhook(req *Request) (any, *telegrambot.Response) {
+	s.webhookURL = req.Params.String("url")
+	if req.Params.Bool("drop_pending_updates") {
+		s.updates = nil
+	}
+
+	return true, nil
+}
+
+func (s *Server) deleteWebhook(req *Request) (any, *telegrambot.Response) {
+	s.webhookURL = ""
+	if req.Params.Bool("drop_pending_updates") {
+		s.updates = nil
+	}
+
+	return true, nil
+}
+
+func (s *Server) getWebhookInfo(req *Request) (any, *telegrambot.Response) {
+	return &telegrambot.WebhookInfo{
+		URL:                s.webhookURL,
+		PendingUpdateCount: len(s.updates),
+	}, nil
+}
+
+// Returns chat by chat identifier or username parameter
+func (s *Server) resolveChat(req *Request, key string) (*telegrambot.Chat, *telegrambot.Response) {
+	switch chatID := req.Params.ChatID(key).(type) {
+	case telegrambot.ChatID:
+		if newChatID, ok := s.migratedChats[chatID]; ok {
+			return nil, &telegrambot.Response{
+				ErrorCode:   400,
+				Description: "Bad Request: group chat was upgraded to a supergroup chat",
+				Parameters: &telegrambot.ResponseParameters{
+					MigrateToChatID: newChatID,
+				},
+			}
+		}
+
+		if chat := s.chats[chatID]; chat != nil {
+			return chat, nil
+		}
+	case telegrambot.Username:
+		for _, chat := range s.chats {
+			if chat.Username != "" && "@"+chat.Username == chatID {
+				return chat, nil
+			}
+		}
+	}
+
+	return nil, errChatNotFound
+}
+
+// Returns message by chat_id and message_id parameters, or by
+// inline_message_id parameter
+func (s *Server) resolveMessage(req *Request, notFound *telegrambot.Response) (*telegrambot.Message, *telegrambot.Response) {
+	if inlineMessageID := telegrambot.InlineMessageID(req.Params.String("inline_message_id")); inlineMessageID != "" {
+		msg := s.inlineMessages[inlineMessageID]
+		if msg == nil {
+			return nil, notFound
+		}
+
+		return msg, nil
+	}
+
+	chat, errResp := s.resolveChat(req, "chat_id")
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	msg := s.messages[chat.ID][telegrambot.MessageID(req.Params.Int64("message_id"))]
+	if msg == nil {
+		return nil, notFound
+	}
+
+	return msg, nil
+}
+
+// Stores message, assigning its identifier and date
+func (s *Server) storeMessage(msg *telegrambot.Message) *telegrambot.Message {
+	if msg.MessageID == 0 {
+		s.lastMessageIDs[msg.Chat.ID]++
+		msg.MessageID = s.lastMessageIDs[msg.Chat.ID]
+	} else if msg.MessageID > s.lastMessageIDs[msg.Chat.ID] {
+		s.lastMessageIDs[msg.Chat.ID] = msg.MessageID
+	}
+
+	if msg.Date == 0 {
+		msg.Date = time.Now().Unix()
+	}
+
+	if s.messages[msg.Chat.ID] == nil {
+		s.messages[msg.Chat.ID] = map[telegrambot.MessageID]*telegrambot.Message{}
+	}
+	s.messages[msg.Chat.ID][msg.MessageID] = msg
+
+	return msg
+}
+
+// Stores message sent by bot
+func (s *Server) storeSentMessage(msg *telegrambot.Message) *telegrambot.Message {
+	s.storeMessage(msg)
+	s.sentMessages = append(s.sentMessages, msg)
+
+	return msg
+}
+
+// Replaces stored message with its edited copy. Messages are never modified in
+// place, so messages returned to tests stay unchanged.
+func (s *Server) storeEditedMessage(msg *telegrambot.Message) {
+	msg.EditDate = time.Now().Unix()
+
+	for inlineMessageID, inlineMsg := range s.inlineMessages {
+		if inlineMsg.Chat.ID == msg.Chat.ID && inlineMsg.MessageID == msg.MessageID {
+			s.inlineMessages[inlineMessageID] = msg
+		}
+	}
+
+	if s.messages[msg.Chat.ID][msg.MessageID] != nil {
+		s.messages[msg.Chat.ID][msg.MessageID] = msg
+	}
+}
+
+// Returns new message from bot to chat, filled with common sending parameters
+func (s *Server) newBotMessage(req *Request, chat *telegrambot.Chat) (*telegrambot.Message, *telegrambot.Response) {
+	msg := &telegrambot.Message{
+		From: s.bot,
+		Chat: chat,
+	}
+
+	if chat.Type == telegrambot.ChatTypeChannel {
+		msg.From = nil
+		msg.SenderChat = chat
+	}
+
+	if replyToMessageID := telegrambot.MessageID(req.Params.Int64("reply_to_message_id")); replyToMessageID != 0 {
+		msg.ReplyToMessage = s.messages[chat.ID][replyToMessageID]
+		if msg.ReplyToMessage == nil && !req.Params.Bool("allow_sending_without_reply") {
+			return nil, errReplyMessageNotFound
+		}
+	}
+
+	msg.HasProtectedContent = req.Params.Bool("protect_content")
+
+	err := decodeInlineKeyboard(req.Params, &msg.ReplyMarkup)
+	if err != nil {
+		return nil, errorResponse(400, "Bad Request: can't parse reply keyboard markup JSON object")
+	}
+
+	return msg, nil
+}
+
+// Decodes reply_markup parameter, if it is an inline keyboard. Other keyboards
+// are not attached to messages.
+func decodeInlineKeyboard(params Params, dest **telegrambot.InlineKeyboardMarkup) error {
+	if !strings.Contains(params.String("reply_markup"), "inline_keyboard") {
+		*dest = nil
+		return nil
+	}
+
+	return params.Decode("reply_markup", dest)
+}
+
+func (s *Server) sendMessage(req *Request) (any, *telegrambot.Response) {
+	chat, errResp := s.resolveChat(req, "chat_id")
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	text := req.Params.String("text")
+	switch {
+	case strings.TrimSpace(text) == "":
+		return nil, errMessageTextEmpty
+	case len([]rune(text)) > 4096:
+		return nil, errMessageTooLong
+	}
+
+	msg, errResp := s.newBotMessage(req, chat)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	msg.Text = text
+	req.Params.Decode("entities", &msg.Entities)
+
+	return s.storeSentMessage(msg), nil
+}
+
+func (s *Server) forwardMessage(req *Request) (any, *telegrambot.Response) {
+	chat, errResp := s.resolveChat(req, "chat_id")
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	fromChat, errResp := s.resolveChat(req, "from_chat_id")
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	original := s.messages[fromChat.ID][telegrambot.MessageID(req.Params.Int64("message_id"))]
+	if original == nil {
+		return nil, errMessageToFwdNotFound
+	}
+
+	msg := *original
+	msg.MessageID = 0
+	msg.Date = 0
+	msg.Chat = chat
+	msg.From = s.bot
+	msg.ForwardFrom = original.From
+	msg.ForwardDate = original.Date
+	msg.ReplyToMessage = nil
+	if fromChat.Type == telegrambot.ChatTypeChannel {
+		msg.ForwardFromChat = fromChat
+		msg.ForwardFromMessageID = original.MessageID
+	}
+
+	return s.storeSentMessage(&msg), nil
+}
+
+func (s *Server) copyMessage(req *Request) (any, *telegrambot.Response) {
+	chat, errResp := s.resolveChat(req, "chat_id")
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	fromChat, errResp := s.resolveChat(req, "from_chat_id")
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	original := s.messages[fromChat.ID][telegrambot.MessageID(req.Params.Int64("message_id"))]
+	if original == nil {
+		return nil, errMessageToCopyNotFound
+	}
+
+	msg, errResp := s.newBotMessage(req, chat)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	sender := msg.From
+	senderChat := msg.SenderChat
+	replyToMessage := msg.ReplyToMessage
+	replyMarkup := msg.ReplyMarkup
+
+	*msg = *original
+	msg.MessageID = 0
+	msg.Date = 0
+	msg.EditDate = 0
+	msg.Chat = chat
+	msg.From = sender
+	msg.SenderChat = senderChat
+	msg.ReplyToMessage = replyToMessage
+	msg.ReplyMarkup = replyMarkup
+
+	if _, ok := req.Params["caption"]; ok {
+		msg.Caption = req.Params.String("caption")
+		msg.CaptionEntities = nil
+		req.Params.Decode("caption_entities", &msg.CaptionEntities)
+	}
+
+	s.storeSentMessage(msg)
+
+	return &telegrambot.MessageIDObject{MessageID: msg.MessageID}, nil
+}
+
+// Handles sendPhoto, sendDocument and other methods sending single file
+func (s *Server) sendMedia(req *Request) (any, *telegrambot.Response) {
+	chat, errResp := s.resolveChat(req, "chat_id")
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	msg, errResp := s.newBotMessage(req, chat)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	// e.x. sendVideoNote -> video_note
+	mediaField := toSnakeCase(strings.TrimPrefix(req.Method, "send"))
+
+	sf, errResp := s.resolveInputFile(req, mediaField)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	file := sf.file
+
+	switch mediaField {
+	case "photo":
+		msg.Photo = []*telegrambot.PhotoSize{{
+			FileID:       file.FileID,
+			FileUniqueID: file.FileUniqueID,
+			Width:        int(req.Params.Int64("width")),
+			Height:       int(req.Params.Int64("height")),
+			FileSize:     file.FileSize,
+		}}
+	case "audio":
+		msg.Audio = &telegrambot.Audio{
+			FileID:       file.FileID,
+			FileUniqueID: file.FileUniqueID,
+			Duration:     int(req.Params.Int64("duration")),
+			Performer:    req.Params.String("performer"),
+			Title:        req.Params.String("title"),
+			FileName:     sf.name,
+			FileSize:     file.FileSize,
+		}
+	case "document":
+		msg.Document = &telegrambot.Document{
+			FileID:       file.FileID,
+			FileUniqueID: file.FileUniqueID,
+			FileName:     sf.name,
+			FileSize:     file.FileSize,
+		}
+	case "video":
+		msg.Video = &telegrambot.Video{
+			FileID:       file.FileID,
+			FileUniqueID: file.FileUniqueID,
+			Width:        int(req.Params.Int64("width")),
+			Height:       int(req.Params.Int64("height")),
+			Duration:     int(req.Params.Int64("duration")),
+			FileName:     sf.name,
+			FileSize:     file.FileSize,
+		}
+	case "animation":
+		msg.Animation = &telegrambot.Animation{
+			FileID:       file.FileID,
+			FileUniqueID: file.FileUniqueID,
+			Width:        int(req.Params.Int64("width")),
+			Height:       int(req.Params.Int64("height")),
+			Duration:     int(req.Params.Int64("duration")),
+			FileName:     sf.name,
+			FileSize:     file.FileSize,
+		}
+	case "voice":
+		msg.Voice = &telegrambot.Voice{
+			FileID:       file.FileID,
+			FileUniqueID: file.FileUniqueID,
+			Duration:     int(req.Params.Int64("duration")),
+			FileSize:     file.FileSize,
+		}
+	case "video_note":
+		msg.VideoNote = &telegrambot.VideoNote{
+			FileID:       file.FileID,
+			FileUniqueID: file.FileUniqueID,
+			Length:       int(req.Params.Int64("length")),
+			Duration:     int(req.Params.Int64("duration")),
+			FileSize:     file.FileSize,
+		}
+	case "sticker":
+		msg.Sticker = &telegrambot.Sticker{
+			FileID:       file.FileID,
+			FileUniqueID: file.FileUniqueID,
+			Type:         telegrambot.StickerTypeRegular,
+			FileSize:     file.FileSize,
+		}
+	}
+
+	if mediaField != "sticker" && mediaField != "video_note" {
+		msg.Caption = req.Params.String("caption")
+		req.Params.Decode("caption_entities", &msg.CaptionEntities)
+	}
+
+	return s.storeSentMessage(msg), nil
+}
+
+// Returns file by InputFile parameter, storing uploaded files and files sent
+// by URL
+func (s *Server) resolveInputFile(req *Request, key string) (*storedFile, *telegrambot.Response) {
+	value := req.Params.String(key)
+
+	if fieldname := strings.TrimPrefix(value, "attach://"); fieldname != value {
+		uploadedFile := req.Files[fieldname]
+		if uploadedFile == nil {
+			return nil, errorResponse(400, fmt.Sprintf("Bad Request: file %q not found in request", fieldname))
+		}
+
+		return s.storeUploadedFile(uploadedFile)
+	}
+
+	// Files may be uploaded as fields without attach://
+	if uploadedFile := req.Files[key]; uploadedFile != nil {
+		return s.storeUploadedFile(uploadedFile)
+	}
+
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return s.storeFile(value[strings.LastIndex(value, "/")+1:], nil), nil
+	}
+
+	sf := s.files[telegrambot.FileID(value)]
+	if sf == nil {
+		return nil, errWrongFileID
+	}
+
+	return sf, nil
+}
+
+// Stores file uploaded by bot, Bot API rejects empty files
+func (s *Server) storeUploadedFile(uploadedFile *UploadedFile) (*storedFile, *telegrambot.Response) {
+	if len(uploadedFile.Data) == 0 {
+		return nil, errFileEmpty
+	}
+
+	return s.storeFile(uploadedFile.Name, uploadedFile.Data), nil
+}
+
+func (s *Server) storeFile(name string, data []byte) *storedFile {
+	id := s.nextID()
+
+	sf := &storedFile{
+		file: &telegrambot.File{
+			FileID:       telegrambot.FileID(fmt.Sprintf("file-%v", id)),
+			FileUniqueID: telegrambot.FileUniqueID(fmt.Sprintf("unique-%v", id)),
+			FileSize:     int64(len(data)),
+			FilePath:     fmt.Sprintf("files/file_%v", id),
+		},
+		name: name,
+		data: data,
+	}
+
+	s.files[sf.file.FileID] = sf
+	s.filesByPath[sf.file.FilePath] = sf
+
+	return sf
+}
+
+func (s *Server) sendChatAction(req *Request) (any, *telegrambot.Response) {
+	_, errResp := s.resolveChat(req, "chat_id")
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	return true, nil
+}
+
+// Returns result of editing method, which is the edited message, or True for
+// inline messages
+func editResult(req *Request, msg *telegrambot.Message) any {
+	if req.Params.String("inline_message_id") != "" {
+		return true
+	}
+
+	return msg
+}
+
+func (s *Server) editMessageText(req *Request) (any, *telegrambot.Response) {
+	original, errResp := s.resolveMessage(req, errMessageToEditNotFound)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	if original.Text == "" {
+		return nil, errorResponse(400, "Bad Request: there is no text in the message to edit")
+	}
+
+	text := req.Params.String("text")
+	if strings.TrimSpace(text) == "" {
+		return nil, errMessageTextEmpty
+	}
+
+	msg := *original
+	msg.Text = text
+	msg.Entities = nil
+	req.Params.Decode("entities", &msg.Entities)
+	decodeInlineKeyboard(req.Params, &msg.ReplyMarkup)
+
+	if msg.Text == original.Text && jsonEqual(msg.Entities, original.Entities) && jsonEqual(msg.ReplyMarkup, original.ReplyMarkup) {
+		return nil, errMessageNotModified
+	}
+
+	s.storeEditedMessage(&msg)
+
+	return editResult(req, &msg), nil
+}
+
+func (s *Server) editMessageCaption(req *Request) (any, *telegrambot.Response) {
+	original, errResp := s.resolveMessage(req, errMessageToEditNotFound)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	if original.Text != "" {
+		return nil, errorResponse(400, "Bad Request: there is no caption in the message to edit")
+	}
+
+	msg := *original
+	msg.Caption = req.Params.String("caption")
+	msg.CaptionEntities = nil
+	req.Params.Decode("caption_entities", &msg.CaptionEntities)
+	decodeInlineKeyboard(req.Params, &msg.ReplyMarkup)
+
+	if msg.Caption == original.Caption && jsonEqual(msg.CaptionEntities, original.CaptionEntities) && jsonEqual(msg.ReplyMarkup, original.ReplyMarkup) {
+		return nil, errMessageNotModified
+	}
+
+	s.storeEditedMessage(&msg)
+
+	return editResult(req, &msg), nil
+}
+
+func (s *Server) editMessageReplyMarkup(req *Request) (any, *telegrambot.Response) {
+	original, errResp := s.resolveMessage(req, errMessageToEditNotFound)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	msg := *original
+	decodeInlineKeyboard(req.Params, &msg.ReplyMarkup)
+
+	if jsonEqual(msg.ReplyMarkup, original.ReplyMarkup) {
+		return nil, errMessageNotModified
+	}
+
+	s.storeEditedMessage(&msg)
+
+	return editResult(req, &msg), nil
+}
+
+func (s *Server) deleteMessage(req *Request) (any, *telegrambot.Response) {
+	msg, errResp := s.resolveMessage(req, errMessageToDelNotFound)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	delete(s.messages[msg.Chat.ID], msg.MessageID)
+
+	return true, nil
+}
+
+func (s *Server) answerCallbackQuery(req *Request) (any, *telegrambot.Response) {
+	callbackQueryID := telegrambot.CallbackQueryID(req.Params.String("callback_query_id"))
+
+	// Callback query can be answered only once
+	if !s.callbackQueries[callbackQueryID] {
+		return nil, errQueryTooOld
+	}
+	s.callbackQueries[callbackQueryID] = false
+
+	s.callbackQueryAnswers = append(s.callbackQueryAnswers, req)
+
+	return true, nil
+}
+
+func (s *Server) getChat(req *Request) (any, *telegrambot.Response) {
+	return s.resolveChat(req, "chat_id")
+}
+
+func (s *Server) getFile(req *Request) (any, *telegrambot.Response) {
+	sf := s.files[telegrambot.FileID(req.Params.String("file_id"))]
+	if sf == nil {
+		return nil, errWrongFileID
+	}
+
+	return sf.file, nil
+}
+
+func jsonEqual(a, b any) bool {
+	aJSON, _ := jsoniterCfg.Marshal(a)
+	bJSON, _ := jsoniterCfg.Marshal(b)
+
+	return string(aJSON) == string(bJSON)
+}
+
+func toSnakeCase(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
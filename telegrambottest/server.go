@@ -0,0 +1,482 @@
+// Fake Telegram Bot API server for testing bots built with telegrambot library,
+// without real bot token and network access. Server keeps chats, messages,
+// callback queries and files in memory, accepts injected updates and records
+// all requests made by the bot.
+//
+//	srv := telegrambottest.NewServer()
+//	defer srv.Close()
+//
+//	api := srv.API()
+//	stop := telegrambot.StartReceivingUpdates(api, receiver)
+//	defer stop()
+//
+//	user := srv.NewUser("John")
+//	srv.AddMessage(user, srv.PrivateChat(user), "/start")
+//
+//	reqs := srv.WaitForRequests("sendMessage", 1, time.Second)
+package telegrambottest
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/nickname76/telegrambot"
+)
+
+// Token accepted by Server by default
+const DefaultToken = "123456:TEST-TOKEN"
+
+var jsoniterCfg = jsoniter.Config{
+	OnlyTaggedField:               true,
+	ObjectFieldMustBeSimpleString: true,
+	CaseSensitive:                 true,
+}.Froze()
+
+// Fake Telegram Bot API server
+type Server struct {
+	// Token, which bot must use. Requests with other tokens fail with 401
+	// Unauthorized.
+	Token string
+
+	httpServer *httptest.Server
+	bot        *telegrambot.User
+
+	mu                   sync.Mutex
+	changed              chan struct{}
+	lastID               int64
+	users                map[telegrambot.UserID]*telegrambot.User
+	chats                map[telegrambot.ChatID]*telegrambot.Chat
+	migratedChats        map[telegrambot.ChatID]telegrambot.ChatID
+	messages             map[telegrambot.ChatID]map[telegrambot.MessageID]*telegrambot.Message
+	lastMessageIDs       map[telegrambot.ChatID]telegrambot.MessageID
+	sentMessages         []*telegrambot.Message
+	inlineMessages       map[telegrambot.InlineMessageID]*telegrambot.Message
+	callbackQueries      map[telegrambot.CallbackQueryID]bool
+	callbackQueryAnswers []*Request
+	files                map[telegrambot.FileID]*storedFile
+	filesByPath          map[string]*storedFile
+	updates              []*telegrambot.Update
+	lastUpdateID         telegrambot.UpdateID
+	webhookURL           string
+	failures             map[string][]*telegrambot.Response
+	requests             []*Request
+}
+
+type storedFile struct {
+	file *telegrambot.File
+	name string
+	data []byte
+}
+
+// Creates and starts Server, which must be closed with Close after use
+func NewServer() *Server {
+	s := &Server{
+		Token: DefaultToken,
+		bot: &telegrambot.User{
+			ID:        123456,
+			IsBot:     true,
+			FirstName: "Test Bot",
+			Username:  "test_bot",
+		},
+
+		changed:         make(chan struct{}),
+		lastID:          1000,
+		users:           map[telegrambot.UserID]*telegrambot.User{},
+		chats:           map[telegrambot.ChatID]*telegrambot.Chat{},
+		migratedChats:   map[telegrambot.ChatID]telegrambot.ChatID{},
+		messages:        map[telegrambot.ChatID]map[telegrambot.MessageID]*telegrambot.Message{},
+		lastMessageIDs:  map[telegrambot.ChatID]telegrambot.MessageID{},
+		inlineMessages:  map[telegrambot.InlineMessageID]*telegrambot.Message{},
+		callbackQueries: map[telegrambot.CallbackQueryID]bool{},
+		files:           map[telegrambot.FileID]*storedFile{},
+		filesByPath:     map[string]*storedFile{},
+		failures:        map[string][]*telegrambot.Response{},
+	}
+
+	s.users[s.bot.ID] = s.bot
+
+	s.httpServer = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Stops Server
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// Returns endpoint URL of Server, to be used as API.EndpointURL
+func (s *Server) EndpointURL() string {
+	return s.httpServer.URL + "/bot"
+}
+
+// Returns API configured to make requests to Server
+func (s *Server) API() *telegrambot.API {
+	return &telegrambot.API{
+		Token:                s.Token,
+		EndpointURL:          s.EndpointURL(),
+		HttpDoRequestContext: telegrambot.DefaultHttpDoRequestContext,
+		HttpDoStreamRequest:  telegrambot.DefaultHttpDoStreamRequest,
+	}
+}
+
+// Returns bot user, which is returned by getMe
+func (s *Server) Bot() *telegrambot.User {
+	return s.bot
+}
+
+// Request made by bot to Server
+type Request struct {
+	// Name of Bot API method, e.x. "sendMessage"
+	Method string
+	// Request parameters
+	Params Params
+	// Uploaded files by their multipart field names
+	Files map[string]*UploadedFile
+	// Error returned to bot, nil if request was successful
+	Error *telegrambot.APIError
+}
+
+// File uploaded with multipart/form-data request
+type UploadedFile struct {
+	Name string
+	Data []byte
+}
+
+// Request parameters. Values of string parameters are stored as is, values of
+// other parameters are stored as JSON, like they are sent in
+// multipart/form-data requests.
+type Params map[string]string
+
+func (p Params) String(key string) string {
+	return p[key]
+}
+
+func (p Params) Int64(key string) int64 {
+	i, _ := strconv.ParseInt(p[key], 10, 64)
+	return i
+}
+
+func (p Params) Bool(key string) bool {
+	return p[key] == "true"
+}
+
+// Decodes JSON parameter into dest
+func (p Params) Decode(key string, dest any) error {
+	value, ok := p[key]
+	if !ok {
+		return nil
+	}
+
+	return jsoniterCfg.UnmarshalFromString(value, dest)
+}
+
+// Returns chat identifier or username parameter, nil if there is no such
+func (p Params) ChatID(key string) telegrambot.ChatIDOrUsername {
+	value, ok := p[key]
+	switch {
+	case !ok || value == "":
+		return nil
+	case strings.HasPrefix(value, "@"):
+		return telegrambot.Username(value)
+	default:
+		id, _ := strconv.ParseInt(value, 10, 64)
+		return telegrambot.ChatID(id)
+	}
+}
+
+// Makes the next request of method fail with specified error. Can be called
+// multiple times to fail several requests.
+func (s *Server) FailNext(method string, errorCode int, description string, parameters *telegrambot.ResponseParameters) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[method] = append(s.failures[method], &telegrambot.Response{
+		OK:          false,
+		ErrorCode:   errorCode,
+		Description: description,
+		Parameters:  parameters,
+	})
+}
+
+// Returns all requests made by bot, in order
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Request(nil), s.requests...)
+}
+
+// Returns requests of method made by bot, in order
+func (s *Server) RequestsOf(method string) []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requestsOf(method)
+}
+
+func (s *Server) requestsOf(method string) []*Request {
+	reqs := []*Request{}
+	for _, req := range s.requests {
+		if req.Method == method {
+			reqs = append(reqs, req)
+		}
+	}
+
+	return reqs
+}
+
+// Waits until bot makes at least count requests of method, and returns them.
+// Returns requests made so far, if timeout exceeds earlier.
+func (s *Server) WaitForRequests(method string, count int, timeout time.Duration) []*Request {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		reqs := s.requestsOf(method)
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(reqs) >= count {
+			return reqs
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return reqs
+		}
+	}
+}
+
+// Returns messages sent by bot, in order. Edited messages are returned in
+// their current state.
+func (s *Server) SentMessages() []*telegrambot.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msgs := []*telegrambot.Message{}
+	for _, msg := range s.sentMessages {
+		if current := s.messages[msg.Chat.ID][msg.MessageID]; current != nil {
+			msgs = append(msgs, current)
+		}
+	}
+
+	return msgs
+}
+
+// Returns current state of message, or nil if it does not exist or was deleted
+func (s *Server) Message(chatID telegrambot.ChatID, messageID telegrambot.MessageID) *telegrambot.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.messages[chatID][messageID]
+}
+
+// Returns answerCallbackQuery requests made by bot, in order
+func (s *Server) CallbackQueryAnswers() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Request(nil), s.callbackQueryAnswers...)
+}
+
+// Returns contents of file uploaded by bot or added with AddFile, and false if
+// there is no such file
+func (s *Server) FileData(fileID telegrambot.FileID) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sf := s.files[fileID]
+	if sf == nil {
+		return nil, false
+	}
+
+	return sf.data, true
+}
+
+// Returns webhook URL set by bot, empty if webhook is not set
+func (s *Server) WebhookURL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.webhookURL
+}
+
+// Wakes up everyone waiting for changes. Must be called with mu locked.
+func (s *Server) notifyChanged() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Server) nextID() int64 {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+
+	if filePath := strings.TrimPrefix(path, "file/bot"+s.Token+"/"); filePath != path {
+		s.serveFile(w, filePath)
+		return
+	}
+
+	if !strings.HasPrefix(path, "bot") || strings.Count(path, "/") != 1 {
+		writeResponse(w, &telegrambot.Response{ErrorCode: 404, Description: "Not Found"})
+		return
+	}
+
+	token, method, _ := strings.Cut(strings.TrimPrefix(path, "bot"), "/")
+	if token != s.Token {
+		writeResponse(w, &telegrambot.Response{ErrorCode: 401, Description: "Unauthorized"})
+		return
+	}
+
+	req, err := parseRequest(method, r)
+	if err != nil {
+		writeResponse(w, &telegrambot.Response{ErrorCode: 400, Description: "Bad Request: " + err.Error()})
+		return
+	}
+
+	// Long polling waits outside of the lock
+	if method == "getUpdates" {
+		s.waitForUpdates(r, req)
+	}
+
+	s.mu.Lock()
+	resp := s.handle(req)
+	if !resp.OK {
+		req.Error = &telegrambot.APIError{
+			Method:      method,
+			ErrorCode:   resp.ErrorCode,
+			Description: resp.Description,
+			Parameters:  resp.Parameters,
+		}
+	}
+	s.requests = append(s.requests, req)
+	s.notifyChanged()
+	s.mu.Unlock()
+
+	writeResponse(w, resp)
+}
+
+func (s *Server) handle(req *Request) *telegrambot.Response {
+	if failures := s.failures[req.Method]; len(failures) != 0 {
+		s.failures[req.Method] = failures[1:]
+		return failures[0]
+	}
+
+	handler := methodHandlers[strings.ToLower(req.Method)]
+	if handler == nil {
+		return &telegrambot.Response{ErrorCode: 404, Description: "Not Found: method not found"}
+	}
+
+	result, err := handler(s, req)
+	if err != nil {
+		return err
+	}
+
+	return &telegrambot.Response{OK: true, Result: result}
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, filePath string) {
+	s.mu.Lock()
+	sf := s.filesByPath[filePath]
+	s.mu.Unlock()
+
+	if sf == nil {
+		http.NotFound(w, nil)
+		return
+	}
+
+	w.Write(sf.data)
+}
+
+func parseRequest(method string, r *http.Request) (*Request, error) {
+	req := &Request{
+		Method: method,
+		Params: Params{},
+		Files:  map[string]*UploadedFile{},
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "multipart/form-data":
+		err := r.ParseMultipartForm(32 << 20)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse multipart form: %w", err)
+		}
+
+		for key, values := range r.MultipartForm.Value {
+			req.Params[key] = values[0]
+		}
+
+		for key, fileHeaders := range r.MultipartForm.File {
+			f, err := fileHeaders[0].Open()
+			if err != nil {
+				return nil, err
+			}
+
+			data, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			req.Files[key] = &UploadedFile{
+				Name: fileHeaders[0].Filename,
+				Data: data,
+			}
+		}
+	default:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(body) == 0 || string(body) == "null" {
+			return req, nil
+		}
+
+		iter := jsoniterCfg.BorrowIterator(body)
+		defer jsoniterCfg.ReturnIterator(iter)
+
+		iter.ReadMapCB(func(i *jsoniter.Iterator, key string) bool {
+			req.Params[key] = i.ReadAny().ToString()
+			return true
+		})
+		if iter.Error != nil && iter.Error != io.EOF {
+			return nil, fmt.Errorf("can't parse JSON: %w", iter.Error)
+		}
+	}
+
+	return req, nil
+}
+
+func writeResponse(w http.ResponseWriter, resp *telegrambot.Response) {
+	statusCode := http.StatusOK
+	if !resp.OK {
+		statusCode = resp.ErrorCode
+	}
+
+	body, err := jsoniterCfg.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
@@ -0,0 +1,186 @@
+package telegrambottest_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nickname76/telegrambot"
+	"github.com/nickname76/telegrambot/telegrambottest"
+)
+
+func TestServerSendMessage(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	user := server.NewUser("Alice")
+	chat := server.PrivateChat(user)
+	api := server.API()
+
+	msg, err := api.SendMessage(&telegrambot.SendMessageParams{
+		ChatID: chat.ID,
+		Text:   "Hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored := server.Message(chat.ID, msg.MessageID); stored == nil || stored.Text != "Hello" {
+		t.Errorf("stored message is %+v", stored)
+	}
+	if msg.From == nil || msg.From.ID != server.Bot().ID {
+		t.Errorf("message is sent by %+v, want bot", msg.From)
+	}
+
+	requests := server.RequestsOf("sendMessage")
+	if len(requests) != 1 || requests[0].Params.String("text") != "Hello" {
+		t.Errorf("sendMessage requests are %+v", requests)
+	}
+
+	_, err = api.SendMessage(&telegrambot.SendMessageParams{
+		ChatID: chat.ID,
+	})
+	if !telegrambot.IsMessageTextEmpty(err) {
+		t.Errorf("SendMessage returned %v, want empty text error", err)
+	}
+
+	_, err = api.SendMessage(&telegrambot.SendMessageParams{
+		ChatID: telegrambot.ChatID(404),
+		Text:   "Hello",
+	})
+	if !telegrambot.IsChatNotFound(err) {
+		t.Errorf("SendMessage returned %v, want chat not found error", err)
+	}
+}
+
+func TestServerGetUpdates(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	user := server.NewUser("Alice")
+	chat := server.PrivateChat(user)
+	first := server.AddMessage(user, chat, "/start")
+	second := server.AddMessage(user, chat, "Hello")
+
+	if entities := first.Message.Entities; len(entities) != 1 || entities[0].Type != telegrambot.MessageEntityTypeBotCommand {
+		t.Errorf("command message has entities %+v", entities)
+	}
+
+	api := server.API()
+
+	updates, err := api.GetUpdates(&telegrambot.GetUpdatesParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 2 || updates[0].UpdateID != first.UpdateID || updates[1].UpdateID != second.UpdateID {
+		t.Fatalf("received updates %+v", updates)
+	}
+
+	// Offset confirms updates before it
+	_, err = api.GetUpdates(&telegrambot.GetUpdatesParams{Offset: second.UpdateID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending := server.PendingUpdates(); len(pending) != 1 || pending[0].UpdateID != second.UpdateID {
+		t.Errorf("pending updates are %+v", pending)
+	}
+}
+
+func TestServerFailNext(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	api := server.API()
+
+	server.FailNext("getMe", 500, "Internal Server Error", nil)
+
+	_, err := api.GetMe()
+	if !telegrambot.IsAPIErrorCode(err, 500) {
+		t.Errorf("GetMe returned %v, want 500 error", err)
+	}
+
+	// Only the next request fails
+	_, err = api.GetMe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := server.RequestsOf("getMe")
+	if len(requests) != 2 || requests[0].Error == nil || requests[1].Error != nil {
+		t.Errorf("getMe requests are %+v", requests)
+	}
+
+	api.Token = "123456:wrong-token"
+	_, err = api.GetMe()
+	if !telegrambot.IsUnauthorized(err) {
+		t.Errorf("GetMe returned %v with wrong token, want 401 error", err)
+	}
+}
+
+func TestServerUploads(t *testing.T) {
+	server := telegrambottest.NewServer()
+	defer server.Close()
+
+	user := server.NewUser("Alice")
+	chat := server.PrivateChat(user)
+
+	for _, streamed := range []bool{false, true} {
+		api := server.API()
+		if !streamed {
+			api.HttpDoStreamRequest = nil
+		}
+
+		msg, err := api.SendDocument(&telegrambot.SendDocumentParams{
+			ChatID: chat.ID,
+			Document: &telegrambot.FileReader{
+				Name:   "report.txt",
+				Reader: strings.NewReader("report"),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, ok := server.FileData(msg.Document.FileID)
+		if !ok || !bytes.Equal(data, []byte("report")) {
+			t.Errorf("uploaded file contains %q", data)
+		}
+		if msg.Document.FileName != "report.txt" || msg.Document.FileSize != int64(len("report")) {
+			t.Errorf("document is %+v", msg.Document)
+		}
+
+		// Bot API rejects empty files
+		_, err = api.SendDocument(&telegrambot.SendDocumentParams{
+			ChatID: chat.ID,
+			Document: &telegrambot.FileReader{
+				Name:   "empty.txt",
+				Reader: strings.NewReader(""),
+			},
+		})
+		if !telegrambot.IsAPIErrorCode(err, 400) || !strings.Contains(err.Error(), "file must be non-empty") {
+			t.Errorf("SendDocument returned %v for empty file, want 400 error", err)
+		}
+	}
+
+	// Files are sent again by their identifiers
+	file := server.AddFile("photo.jpg", []byte("photo"))
+
+	msg, err := server.API().SendDocument(&telegrambot.SendDocumentParams{
+		ChatID:   chat.ID,
+		Document: file.FileID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Document.FileID != file.FileID {
+		t.Errorf("sent document %v, want %v", msg.Document.FileID, file.FileID)
+	}
+
+	_, err = server.API().SendDocument(&telegrambot.SendDocumentParams{
+		ChatID:   chat.ID,
+		Document: telegrambot.FileID("unknown"),
+	})
+	if !telegrambot.IsWrongFileID(err) {
+		t.Errorf("SendDocument returned %v for unknown file, want wrong file identifier error", err)
+	}
+}
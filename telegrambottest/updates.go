@@ -0,0 +1,207 @@
+package telegrambottest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/nickname76/telegrambot"
+)
+
+// Creates new user, which is not a bot
+func (s *Server) NewUser(firstName string) *telegrambot.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := &telegrambot.User{
+		ID:           telegrambot.UserID(s.nextID()),
+		FirstName:    firstName,
+		LanguageCode: telegrambot.LanguageCodeEnglish,
+	}
+	s.users[user.ID] = user
+
+	return user
+}
+
+// Returns private chat of bot with user, creating it if needed
+func (s *Server) PrivateChat(user *telegrambot.User) *telegrambot.Chat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chatID := telegrambot.ChatID(user.ID)
+	if chat := s.chats[chatID]; chat != nil {
+		return chat
+	}
+
+	chat := &telegrambot.Chat{
+		ID:        chatID,
+		Type:      telegrambot.ChatTypePrivate,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Username:  user.Username,
+	}
+	s.chats[chatID] = chat
+
+	return chat
+}
+
+// Creates new chat of chatType, which is group, supergroup or channel
+func (s *Server) NewChat(chatType telegrambot.ChatType, title string) *telegrambot.Chat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID()
+	if chatType != telegrambot.ChatTypeGroup {
+		id += 1000000000000
+	}
+
+	chat := &telegrambot.Chat{
+		ID:    telegrambot.ChatID(-id),
+		Type:  chatType,
+		Title: title,
+	}
+	s.chats[chat.ID] = chat
+
+	return chat
+}
+
+// Registers chat, so that bot can send messages to it
+func (s *Server) AddChat(chat *telegrambot.Chat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chats[chat.ID] = chat
+}
+
+// Upgrades group to a new supergroup, requests to the group fail with
+// migrate_to_chat_id afterwards
+func (s *Server) MigrateChat(groupChatID telegrambot.ChatID) (*telegrambot.Chat, error) {
+	s.mu.Lock()
+	group := s.chats[groupChatID]
+	s.mu.Unlock()
+
+	if group == nil || group.Type != telegrambot.ChatTypeGroup {
+		return nil, fmt.Errorf("MigrateChat: group %v not found", groupChatID)
+	}
+
+	supergroup := s.NewChat(telegrambot.ChatTypeSupergroup, group.Title)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.chats, groupChatID)
+	s.migratedChats[groupChatID] = supergroup.ID
+
+	return supergroup, nil
+}
+
+// Stores file, which bot can send by its identifier or download with getFile
+func (s *Server) AddFile(name string, data []byte) *telegrambot.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.storeFile(name, data).file
+}
+
+// Adds update to be received by bot, assigning its UpdateID. Chats, messages
+// and callback queries of update are registered, so that bot can reply to
+// them.
+func (s *Server) AddUpdate(update *telegrambot.Update) *telegrambot.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastUpdateID++
+	update.UpdateID = s.lastUpdateID
+
+	for _, msg := range []*telegrambot.Message{update.Message, update.ChannelPost} {
+		if msg != nil {
+			s.registerChat(msg.Chat)
+			s.storeMessage(msg)
+		}
+	}
+
+	for _, msg := range []*telegrambot.Message{update.EditedMessage, update.EditedChannelPost} {
+		if msg != nil {
+			s.registerChat(msg.Chat)
+			if s.messages[msg.Chat.ID][msg.MessageID] != nil {
+				s.messages[msg.Chat.ID][msg.MessageID] = msg
+			}
+		}
+	}
+
+	if cbQry := update.CallbackQuery; cbQry != nil {
+		s.callbackQueries[cbQry.ID] = true
+	}
+
+	for _, chatMember := range []*telegrambot.ChatMemberUpdated{update.MyChatMember, update.ChatMember} {
+		if chatMember != nil {
+			s.registerChat(chatMember.Chat)
+		}
+	}
+
+	if update.ChatJoinRequest != nil {
+		s.registerChat(update.ChatJoinRequest.Chat)
+	}
+
+	s.updates = append(s.updates, update)
+	s.notifyChanged()
+
+	return update
+}
+
+func (s *Server) registerChat(chat *telegrambot.Chat) {
+	if chat != nil && s.chats[chat.ID] == nil {
+		s.chats[chat.ID] = chat
+	}
+}
+
+// Adds update with text message from user to chat. Bot command at the start of
+// text is marked with entity.
+func (s *Server) AddMessage(from *telegrambot.User, chat *telegrambot.Chat, text string) *telegrambot.Update {
+	msg := &telegrambot.Message{
+		From: from,
+		Chat: chat,
+		Date: time.Now().Unix(),
+		Text: text,
+	}
+
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		msg.Entities = []*telegrambot.MessageEntity{{
+			Type:   telegrambot.MessageEntityTypeBotCommand,
+			Offset: 0,
+			Length: len(utf16.Encode([]rune(command))),
+		}}
+	}
+
+	return s.AddUpdate(&telegrambot.Update{
+		Message: msg,
+	})
+}
+
+// Adds update with callback query from user, who pressed inline keyboard
+// button with data under msg
+func (s *Server) AddCallbackQuery(from *telegrambot.User, msg *telegrambot.Message, data string) *telegrambot.Update {
+	s.mu.Lock()
+	id := s.nextID()
+	s.mu.Unlock()
+
+	return s.AddUpdate(&telegrambot.Update{
+		CallbackQuery: &telegrambot.CallbackQuery{
+			ID:           telegrambot.CallbackQueryID(fmt.Sprint(id)),
+			From:         from,
+			Message:      msg,
+			ChatInstance: telegrambot.ChatInstance(fmt.Sprint(msg.Chat.ID)),
+			Data:         data,
+		},
+	})
+}
+
+// Returns updates, which were not yet confirmed by bot
+func (s *Server) PendingUpdates() []*telegrambot.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*telegrambot.Update(nil), s.updates...)
+}
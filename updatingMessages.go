@@ -38,7 +38,7 @@ type EditMessageTextParams struct {
 func (api *API) EditMessageText(params *EditMessageTextParams) (*Message, error) {
 	var msg *Message
 
-	if params.InlineMessageID != "" {
+	if params.InlineMessageID == "" {
 		msg = &Message{}
 	}
 
@@ -92,7 +92,7 @@ type EditMessageCaptionParams struct {
 func (api *API) EditMessageCaption(params *EditMessageCaptionParams) (*Message, error) {
 	var msg *Message
 
-	if params.InlineMessageID != "" {
+	if params.InlineMessageID == "" {
 		msg = &Message{}
 	}
 
@@ -143,7 +143,7 @@ type EditMessageMediaParams struct {
 func (api *API) EditMessageMedia(params *EditMessageMediaParams) (*Message, error) {
 	var msg *Message
 
-	if params.InlineMessageID != "" {
+	if params.InlineMessageID == "" {
 		msg = &Message{}
 	}
 
@@ -187,7 +187,7 @@ type EditMessageReplyMarkupParams struct {
 func (api *API) EditMessageReplyMarkup(params *EditMessageReplyMarkupParams) (*Message, error) {
 	var msg *Message
 
-	if params.InlineMessageID != "" {
+	if params.InlineMessageID == "" {
 		msg = &Message{}
 	}
 